@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	kuma "github.com/breml/go-uptime-kuma-client"
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+	"github.com/gitisz/uptime-kuma-agent/internal/provision"
+)
+
+// runIsCached reports whether the config and live Uptime Kuma state match the last
+// successful run. Any error just means the run goes ahead.
+func runIsCached(ctx context.Context, client *kuma.Client, cachePath, configHash string) bool {
+	cache, err := provision.LoadRunCache(cachePath)
+	if err != nil {
+		logging.Warnf("Ignoring run cache: %v", err)
+		return false
+	}
+	if cache == nil {
+		return false
+	}
+
+	stateHash, err := provision.StateHash(ctx, client)
+	if err != nil {
+		logging.Warnf("Ignoring run cache: %v", err)
+		return false
+	}
+	return cache.Matches(configHash, stateHash)
+}
+
+// saveRunCache records the post-provisioning config and state. The config is reloaded
+// because provisioning may have written push tokens back to disk.
+func saveRunCache(ctx context.Context, client *kuma.Client, cachePath string, opts provision.Options) {
+	cfg, err := loadMergedConfig()
+	if err != nil {
+		logging.Warnf("Not updating run cache: %v", err)
+		return
+	}
+	configHash, err := provision.ConfigHash(cfg, opts)
+	if err != nil {
+		logging.Warnf("Not updating run cache: %v", err)
+		return
+	}
+	stateHash, err := provision.StateHash(ctx, client)
+	if err != nil {
+		logging.Warnf("Not updating run cache: %v", err)
+		return
+	}
+
+	cache := &provision.RunCache{ConfigHash: configHash, StateHash: stateHash, UpdatedAt: time.Now().UTC()}
+	if err := cache.Save(cachePath); err != nil {
+		logging.Warnf("Failed to save run cache: %v", err)
+		return
+	}
+	logging.Debugf("Saved run cache to %s", cachePath)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+)
+
+var healthAddr string
+
+// runStatus tracks the latest reconcile in watch mode for the readiness endpoint.
+type runStatus struct {
+	mu       sync.RWMutex
+	finished time.Time
+	err      error
+}
+
+// record stores the outcome of a reconcile that just finished.
+func (s *runStatus) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finished = time.Now().UTC()
+	s.err = err
+}
+
+// ready reports whether the latest reconcile succeeded, with its finish time and error.
+func (s *runStatus) ready() (bool, time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.finished.IsZero() && s.err == nil, s.finished, s.err
+}
+
+// handleHealth registers the probe endpoints on mux: /healthz answers while the process
+// is up, /readyz only once the latest provisioning run succeeded.
+func handleHealth(mux *http.ServeMux, status *runStatus) {
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		ready, finished, err := status.ready()
+		body := struct {
+			Ready   bool       `json:"ready"`
+			LastRun *time.Time `json:"last_run,omitempty"`
+			Error   string     `json:"error,omitempty"`
+		}{Ready: ready}
+		if !finished.IsZero() {
+			body.LastRun = &finished
+		}
+		if err != nil {
+			body.Error = err.Error()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(body)
+	})
+}
+
+// serveHTTP serves mux on addr in the background until the returned server is closed.
+func serveHTTP(addr string, mux *http.ServeMux) *http.Server {
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Errorf("HTTP server on %s stopped: %v", addr, err)
+		}
+	}()
+	logging.Infof("Serving HTTP endpoints on %s", addr)
+	return server
+}
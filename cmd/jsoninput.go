@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+)
+
+// jsonMetric is a metric as written by Telegraf's json serializer.
+type jsonMetric struct {
+	Name   string            `json:"name"`
+	Tags   map[string]string `json:"tags"`
+	Fields map[string]any    `json:"fields"`
+}
+
+// isJSONInput reports whether the first non-empty line starts a JSON object or array.
+func isJSONInput(lines []string) bool {
+	for _, line := range lines {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")
+		}
+	}
+	return false
+}
+
+// findJSONField returns every numeric value of field across the JSON input, which may
+// be one metric object per line, a batch ({"metrics": [...]}) or an array of metrics.
+// Only metrics carrying all of tags are considered.
+func findJSONField(input, field string, tags map[string]string) ([]float64, error) {
+	metrics, err := parseJSONMetrics(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []float64
+	for _, m := range metrics {
+		if !matchTags(m.Tags, tags) {
+			continue
+		}
+		raw, ok := m.Fields[field]
+		if !ok {
+			continue
+		}
+
+		v, ok := raw.(float64)
+		if !ok {
+			return nil, fmt.Errorf("field '%s' cannot be compared to a threshold: %w: %T value %v", field, errNonNumericField, raw, raw)
+		}
+
+		values = append(values, v)
+		logging.Debugf("PARSED %.6f from field '%s' (metric %s)", v, field, m.Name)
+	}
+
+	return values, nil
+}
+
+// parseJSONMetrics decodes every JSON value in input into metrics.
+func parseJSONMetrics(input string) ([]jsonMetric, error) {
+	var metrics []jsonMetric
+
+	dec := json.NewDecoder(strings.NewReader(input))
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return metrics, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON input: %w", err)
+		}
+
+		switch trimmed := strings.TrimSpace(string(raw)); {
+		case strings.HasPrefix(trimmed, "["):
+			var batch []jsonMetric
+			if err := json.Unmarshal(raw, &batch); err != nil {
+				return nil, fmt.Errorf("invalid JSON metric array: %w", err)
+			}
+			metrics = append(metrics, batch...)
+
+		default:
+			var obj struct {
+				jsonMetric
+				Metrics []jsonMetric `json:"metrics"`
+			}
+			if err := json.Unmarshal(raw, &obj); err != nil {
+				return nil, fmt.Errorf("invalid JSON metric: %w", err)
+			}
+			if obj.Metrics != nil {
+				metrics = append(metrics, obj.Metrics...)
+			} else {
+				metrics = append(metrics, obj.jsonMetric)
+			}
+		}
+	}
+}
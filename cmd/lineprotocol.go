@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// errNonNumericField is returned when a string or boolean field is used as a numeric value.
+var errNonNumericField = errors.New("field is not numeric")
+
+// lineProtocolPoint is a single parsed InfluxDB line protocol point. Field values are
+// kept raw (with their type suffix) and decoded with parseFieldValue.
+type lineProtocolPoint struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]string
+}
+
+// parseLineProtocol parses "measurement[,tag=v...] field=v[,field=v...] [timestamp]",
+// honoring backslash escapes and quoted string field values.
+func parseLineProtocol(line string) (lineProtocolPoint, error) {
+	sections := splitUnescaped(strings.TrimSpace(line), ' ')
+	if len(sections) < 2 {
+		return lineProtocolPoint{}, fmt.Errorf("expected measurement and fields, got %q", line)
+	}
+
+	point := lineProtocolPoint{
+		Tags:   make(map[string]string),
+		Fields: make(map[string]string),
+	}
+
+	series := splitUnescaped(sections[0], ',')
+	point.Measurement = unescape(series[0])
+	for _, tag := range series[1:] {
+		key, value, ok := strings.Cut(tag, "=")
+		if !ok {
+			return lineProtocolPoint{}, fmt.Errorf("malformed tag %q", tag)
+		}
+		point.Tags[unescape(key)] = unescape(value)
+	}
+
+	for _, field := range splitUnescaped(sections[1], ',') {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return lineProtocolPoint{}, fmt.Errorf("malformed field %q", field)
+		}
+		point.Fields[unescape(key)] = value
+	}
+
+	return point, nil
+}
+
+// parseFieldValue decodes a raw line protocol field value into a float, handling the
+// integer (42i), unsigned (42u) and float (1.2e9) forms. String ("...") and boolean
+// (t/f/true/false) values return errNonNumericField.
+func parseFieldValue(raw string) (float64, error) {
+	if raw == "" {
+		return 0, fmt.Errorf("empty field value")
+	}
+
+	if strings.HasPrefix(raw, `"`) {
+		return 0, fmt.Errorf("%w: string value %s", errNonNumericField, raw)
+	}
+
+	switch raw {
+	case "t", "T", "true", "True", "TRUE", "f", "F", "false", "False", "FALSE":
+		return 0, fmt.Errorf("%w: boolean value %s", errNonNumericField, raw)
+	}
+
+	switch raw[len(raw)-1] {
+	case 'i':
+		v, err := strconv.ParseInt(raw[:len(raw)-1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid integer value %q: %w", raw, err)
+		}
+		return float64(v), nil
+	case 'u':
+		v, err := strconv.ParseUint(raw[:len(raw)-1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid unsigned value %q: %w", raw, err)
+		}
+		return float64(v), nil
+	}
+
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid float value %q: %w", raw, err)
+	}
+	return v, nil
+}
+
+// splitUnescaped splits s on sep, ignoring separators that are backslash-escaped or
+// inside double quotes.
+func splitUnescaped(s string, sep byte) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++ // skip escaped character
+		case '"':
+			inQuotes = !inQuotes
+		case sep:
+			if inQuotes {
+				continue
+			}
+			if i > start || sep != ' ' {
+				parts = append(parts, s[start:i])
+			}
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+// unescape removes line protocol backslash escapes from keys and tag values.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseFieldValue(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want float64
+	}{
+		{"42i", 42},
+		{"-7i", -7},
+		{"42u", 42},
+		{"18446744073709551615u", 18446744073709551615},
+		{"12.5", 12.5},
+		{"-0.25", -0.25},
+		{"1.2e9", 1.2e9},
+		{"3", 3},
+	}
+	for _, tt := range tests {
+		got, err := parseFieldValue(tt.raw)
+		if err != nil {
+			t.Errorf("parseFieldValue(%q): %v", tt.raw, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseFieldValue(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseFieldValueErrors(t *testing.T) {
+	for _, raw := range []string{`"up"`, `""`, "t", "T", "true", "True", "TRUE", "f", "F", "false", "False", "FALSE"} {
+		if _, err := parseFieldValue(raw); !errors.Is(err, errNonNumericField) {
+			t.Errorf("parseFieldValue(%q) error = %v, want errNonNumericField", raw, err)
+		}
+	}
+
+	for _, raw := range []string{"", "-1u", "1.5i", "12abc", "i"} {
+		_, err := parseFieldValue(raw)
+		if err == nil {
+			t.Errorf("parseFieldValue(%q) succeeded, want an error", raw)
+		} else if errors.Is(err, errNonNumericField) {
+			t.Errorf("parseFieldValue(%q) = errNonNumericField, want a parse error", raw)
+		}
+	}
+}
+
+func TestParseLineProtocol(t *testing.T) {
+	point, err := parseLineProtocol(`disk,path=/mnt/my\ data,host=h1 used_percent=81.5,mode="rw ok",inodes=12i 1700000000000000000`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := lineProtocolPoint{
+		Measurement: "disk",
+		Tags:        map[string]string{"path": "/mnt/my data", "host": "h1"},
+		Fields:      map[string]string{"used_percent": "81.5", "mode": `"rw ok"`, "inodes": "12i"},
+	}
+	if !reflect.DeepEqual(point, want) {
+		t.Errorf("parseLineProtocol = %+v, want %+v", point, want)
+	}
+
+	if _, err := parseLineProtocol("disk"); err == nil {
+		t.Error("parseLineProtocol accepted a line without fields")
+	}
+}
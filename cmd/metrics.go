@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+	"github.com/gitisz/uptime-kuma-agent/internal/metrics"
+	"github.com/gitisz/uptime-kuma-agent/internal/provision"
+)
+
+var (
+	metricsAddr    string
+	pushgatewayURL string
+)
+
+// pushgatewayJob is the Pushgateway job the run metrics are grouped under.
+const pushgatewayJob = "uptime_kuma_agent"
+
+// observeRun records a finished run in the Prometheus metrics and, when configured,
+// pushes them to the Pushgateway. Dry runs change nothing and are left out.
+func observeRun(report *provision.RunReport) {
+	if report.DryRun {
+		return
+	}
+	metrics.Observe(report)
+
+	if pushgatewayURL != "" {
+		if err := metrics.Push(pushgatewayURL, pushgatewayJob); err != nil {
+			logging.Warnf("Warning: %v", err)
+		}
+	}
+}
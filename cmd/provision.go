@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+	"github.com/gitisz/uptime-kuma-agent/internal/telegraf"
+	"github.com/spf13/cobra"
+)
+
+var provisionCmd = &cobra.Command{
+	Use:   "provision",
+	Short: "Provision notifications, groups and monitors in Uptime Kuma without touching Telegraf",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkOutputFormat(); err != nil {
+			logging.Fatal(err)
+		}
+		cfg, err := loadConfig()
+		if err != nil {
+			logging.Fatal(err)
+		}
+		if err := runProvision(cfg); err != nil {
+			logging.Fatal(err)
+		}
+	},
+}
+
+var generateTelegrafCmd = &cobra.Command{
+	Use:   "generate-telegraf",
+	Short: "Regenerate the Telegraf drop-in configs without contacting Uptime Kuma",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig()
+		if err != nil {
+			logging.Fatal(err)
+		}
+		// Push tokens come from the tokens file written by the last provisioning run
+		if err := telegraf.GenerateTelegrafConfigs(cfg, telegrafDir, dryRun); err != nil {
+			logging.Fatalf("Failed to generate Telegraf configs: %v", err)
+		}
+	},
+}
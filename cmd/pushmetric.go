@@ -2,14 +2,22 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gitisz/uptime-kuma-agent/internal/config"
 	"github.com/gitisz/uptime-kuma-agent/internal/logging"
@@ -23,6 +31,11 @@ var pushMetricCmd = &cobra.Command{
 		monitorName := cmd.Flag("monitor").Value.String()
 		groupName := cmd.Flag("group").Value.String()
 		token := cmd.Flag("token").Value.String()
+		tagArgs, _ := cmd.Flags().GetStringArray("tag")
+		tags, err := parseTagFilters(tagArgs)
+		if err != nil {
+			logging.Fatalf("Invalid --tag: %v", err)
+		}
 
 		if monitorName == "" || token == "" {
 			logging.Fatalf("Missing required flags: monitor=%q group=%q token=%q", monitorName, groupName, token)
@@ -32,34 +45,89 @@ var pushMetricCmd = &cobra.Command{
 		logging.Infof("Monitor: %s", monitorName)
 		logging.Infof("Group: %s", groupName)
 		logging.Infof("Token: %s", token)
-		logging.Infof("Config path: %s", configPath)
 
-		// Load full config
-		cfg, err := config.LoadMergedConfig(filepath.Dir(configPath))
-		if err != nil {
-			logging.Fatalf("Failed to load merged config: %v", err)
+		// Generated exec commands carry the monitor's settings; older ones need the config
+		var cfg *config.Config
+		var m config.MonitorConfig
+		matched := false
+		if spec := cmd.Flag("spec").Value.String(); spec != "" {
+			if cfg, m, err = config.ParsePushSpec(spec, monitorName, groupName); err != nil {
+				logging.Fatalf("Invalid --spec: %v", err)
+			}
+			matched = true
+		} else {
+			logging.Infof("Config directory: %s", mergeDir())
+			if cfg, err = loadMergedConfig(); err != nil {
+				logging.Fatalf("Failed to load merged config: %v", err)
+			}
+			logging.Debugf("Looking for monitor: name=%q, group=%q", monitorName, groupName)
+			m, matched = findPushMonitor(cfg, monitorName, groupName)
+		}
+
+		// Flags of the generated exec command win over the spec and the config
+		flags := cmd.Flags()
+		if flags.Changed("field") {
+			m.Field, _ = flags.GetString("field")
+			if !matched {
+				m.Name, m.Group, m.Type, matched = monitorName, groupName, "push", true
+			}
+		}
+		if flags.Changed("threshold") {
+			threshold, _ := flags.GetFloat64("threshold")
+			m.Threshold = &threshold
+		}
+		if flags.Changed("operator") {
+			m.Operator, _ = flags.GetString("operator")
+		}
+		if flags.Changed("unit") {
+			unit, _ := flags.GetString("unit")
+			m.Unit = &unit
 		}
 
-		pushURL := fmt.Sprintf("%s/api/push/%s", strings.TrimSuffix(cfg.UptimeKumaURL, "/"), token)
+		pushURL, err := buildPushURL(cfg.UptimeKumaURL, token)
+		if err != nil {
+			logging.Fatalf("Invalid uptime_kuma_url %q: %v", cfg.UptimeKumaURL, err)
+		}
 		logging.Infof("Push URL: %s", pushURL)
 
 		// Find threshold and field from config.yaml using monitor name + group matching
 		threshold := 90.0
+		operator := "gt"
+		aggregation := "last"
+		unit := "%"
 		expectedField := ""
+		var extraFields []string
+		pingField := ""
+		statusScript := ""
+		var monitor config.MonitorConfig
 
-		logging.Debugf("Looking for monitor: name=%q, group=%q", monitorName, groupName)
-
-		for _, m := range cfg.GetAllMonitors() {
-			if m.Type == "push" && m.Name == monitorName && m.Group == groupName {
-				if m.Threshold > 0 {
-					threshold = m.Threshold
-				}
-				if m.Field != "" {
-					expectedField = m.Field
-				}
-				logging.Debugf("Found matching monitor: %s (group: %s, metric: %s)", m.Name, m.Group, m.Metric)
-				break
+		if matched {
+			unit = m.ValueUnit()
+			if m.Threshold != nil {
+				threshold = *m.Threshold
+			}
+			if m.Field != "" {
+				expectedField = m.Field
+				extraFields = m.PushFields()[1:]
+				pingField = m.PingField
+			}
+			if m.Operator != "" {
+				operator = strings.ToLower(m.Operator)
+			}
+			if m.Aggregation != "" {
+				aggregation = strings.ToLower(m.Aggregation)
+			}
+			statusScript = m.StatusScript
+			monitor = m
+			if len(tags) == 0 {
+				// Configs generated before --tag existed: derive the filter from the monitor
+				tags, _ = parseTagFilters(m.TagFilters())
 			}
+			logging.Debugf("Found matching monitor: %s (group: %s, metric: %s)", m.Name, m.Group, m.Metric)
+		}
+
+		if len(tags) > 0 {
+			logging.Infof("Tag filter: %v", tags)
 		}
 
 		// Enforce that field is defined
@@ -67,59 +135,72 @@ var pushMetricCmd = &cobra.Command{
 			logging.Fatalf("CRITICAL: No 'field' defined for monitor %q in config.yaml", monitorName)
 		}
 
-		logging.Infof("Threshold from config.yaml: %.1f", threshold)
+		symbol, ok := operatorSymbols[operator]
+		if !ok {
+			logging.Fatalf("CRITICAL: unknown operator %q for monitor %q (expected gt, gte, lt, lte or eq)", operator, monitorName)
+		}
+
+		if !slices.Contains(config.Aggregations, aggregation) {
+			logging.Fatalf("CRITICAL: unknown aggregation %q for monitor %q (expected %s)", aggregation, monitorName, strings.Join(config.Aggregations, ", "))
+		}
+
+		if monitor.HasRange() {
+			logging.Infof("Range from config.yaml: %s (down outside the range)", describeRange(&monitor, unit))
+		} else {
+			logging.Infof("Threshold from config.yaml: %s (down when value %s threshold)", formatValue(threshold, unit, -1), symbol)
+		}
 		logging.Infof("Expecting field: %s", expectedField)
+		if len(extraFields) > 0 {
+			logging.Infof("Extra fields: %s", strings.Join(extraFields, ", "))
+		}
+		if pingField == "" {
+			pingField = expectedField
+		}
 		// READ ALL FROM STDIN
-		var value float64
-		found := false
-		lineCount := 0
-		var receivedLines []string // for debug on failure
+		var receivedLines []string // for parsing and debug on failure
 
 		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024) // batched JSON can be one long line
 		for scanner.Scan() {
 			line := scanner.Text()
-			lineCount++
 			receivedLines = append(receivedLines, line)
-			logging.Debugf("STDIN line %d: %s", lineCount, line)
-
-			// Robust parsing: find field even if surrounded by tags or other fields
-			if strings.Contains(line, expectedField+"=") {
-				// Find the start of the value
-				idx := strings.Index(line, expectedField+"=")
-				rest := line[idx+len(expectedField)+1:]
-
-				// Extract value until comma or end
-				valStr := strings.SplitN(rest, ",", 2)[0]
-				valStr = strings.SplitN(valStr, " ", 2)[0]
-				valStr = strings.TrimSpace(valStr)
-
-				// Remove any trailing unit suffix (like 'u') if present
-				if len(valStr) > 0 && valStr[len(valStr)-1] == 'u' {
-					valStr = valStr[:len(valStr)-1]
-				}
-
-				if v, err := strconv.ParseFloat(valStr, 64); err == nil {
-					value = v
-					found = true
-					logging.Debugf("PARSED %.6f from field '%s' (raw value: %q)", value, expectedField, valStr)
-				} else {
-					logging.Errorf("PARSE FAILED for field '%s': raw value %q → error: %v", expectedField, valStr, err)
-				}
-			}
+			logging.Debugf("STDIN line %d: %s", len(receivedLines), line)
 		}
+		lineCount := len(receivedLines)
 
 		if err := scanner.Err(); err != nil {
 			logging.Errorf("Error reading STDIN: %v", err)
 			os.Exit(1)
 		}
 
-		logging.Infof("Total lines read from STDIN: %d | Found matching field: %v", lineCount, found)
-
 		if lineCount == 0 {
 			logging.Errorf("CRITICAL: NO DATA RECEIVED ON STDIN — Telegraf sent nothing!")
 			os.Exit(1)
 		}
 
+		// Telegraf's json serializer starts with an object or array; anything else is line protocol
+		jsonInput := isJSONInput(receivedLines)
+		if jsonInput {
+			logging.Info("Detected JSON input format")
+		} else {
+			logging.Info("Detected line protocol input format")
+		}
+		readField := func(field string) ([]float64, error) {
+			if jsonInput {
+				return findJSONField(strings.Join(receivedLines, "\n"), field, tags)
+			}
+			return findLineProtocolField(receivedLines, field, tags)
+		}
+
+		values, err := readField(expectedField)
+		if err != nil {
+			logging.Errorf("CRITICAL: %v", err)
+			os.Exit(1)
+		}
+
+		found := len(values) > 0
+		logging.Infof("Total lines read from STDIN: %d | Found matching field: %v (%d value(s))", lineCount, found, len(values))
+
 		if !found {
 			logging.Errorf("FAILED: Expected field '%s=' not found in any line", expectedField)
 			logging.Errorf("Received %d line(s):", lineCount)
@@ -129,32 +210,410 @@ var pushMetricCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Determine status
-		status := "up"
-		if value > threshold {
-			status = "down"
+		value := aggregateValues(aggregation, values)
+		logging.Infof("Using %s of %d value(s): %.6f", aggregation, len(values), value)
+
+		// A recovery threshold and skipping unchanged pushes need the previous push
+		alwaysPush, _ := cmd.Flags().GetBool("always-push")
+		skipUnchanged := monitor.PushEpsilon != nil && !alwaysPush
+		keepState := monitor.RecoveryThreshold != nil || skipUnchanged
+		stateDir, _ := cmd.Flags().GetString("state-dir")
+		if stateDir == "" {
+			stateDir = filepath.Join(logging.GetInternalLogDirectory(&cfg.Agent.Logging), pushStateDir)
+		}
+		statePath := pushStatePath(stateDir, groupName, monitorName)
+		var state *pushState
+		if keepState {
+			if state, err = loadPushState(statePath); err != nil {
+				logging.Warnf("Ignoring push state: %v", err)
+			} else if state != nil {
+				logging.Infof("Previous push: %s (%s at %s)", state.Status, formatValue(state.Value, unit, 2), state.PushedAt.Format(time.RFC3339))
+			}
+		}
+		previous := ""
+		if state != nil {
+			previous = state.Status
+		}
+
+		// Determine status and build message
+		status, msg := evaluatePush(&monitor, value, threshold, operator, unit, previous)
+
+		// Report the extra fields next to the evaluated one
+		fields := map[string]float64{expectedField: value}
+		for _, field := range extraFields {
+			extra, err := readField(field)
+			if err != nil {
+				logging.Warnf("Skipping extra field '%s': %v", field, err)
+				continue
+			}
+			if len(extra) == 0 {
+				logging.Warnf("Skipping extra field '%s': not found in any line", field)
+				continue
+			}
+			fields[field] = aggregateValues(aggregation, extra)
+			msg += fmt.Sprintf(", %s: %s", field, formatValue(fields[field], monitor.FieldUnit(field), 2))
 		}
 
-		// Build message and URL
-		msg := fmt.Sprintf("%s: %.2f%% (threshold %.0f%%)", monitorName, value, threshold)
-		fullURL := fmt.Sprintf("%s?status=%s&ping=%.2f&msg=%s", pushURL, status, value, url.QueryEscape(msg))
+		// Let an opted-in status script override the threshold evaluation
+		if statusScript != "" {
+			if cfg.Agent.AllowStatusScript == nil || !*cfg.Agent.AllowStatusScript {
+				logging.Fatalf("CRITICAL: monitor %q defines status_script but agent.allow_status_scripts is not enabled", monitorName)
+			}
+			scriptStatus, scriptMsg, err := runStatusScript(statusScript, statusScriptInput{
+				Monitor:   monitorName,
+				Group:     groupName,
+				Threshold: threshold,
+				Fields:    fields,
+			})
+			if err != nil {
+				logging.Errorf("Status script %s failed: %v", statusScript, err)
+				os.Exit(1)
+			}
+			status = scriptStatus
+			if scriptMsg != "" {
+				msg = scriptMsg
+			}
+			logging.Infof("Status script %s decided: %s (%s)", statusScript, status, msg)
+		}
+
+		if skipUnchanged && unchangedPush(state, status, value, *monitor.PushEpsilon, heartbeatInterval(cfg)) {
+			logging.Infof("PUSH SKIPPED: %s unchanged since %s (%s, %s)", monitorName, state.PushedAt.Format(time.RFC3339), status, formatValue(value, unit, 2))
+			os.Exit(0)
+		}
+
+		// Build URL
+		query := url.Values{}
+		query.Set("status", status)
+		if ping, ok := fields[pingField]; ok {
+			query.Set("ping", strconv.FormatFloat(ping, 'f', 2, 64))
+		} else {
+			logging.Warnf("Ping field '%s' not found; sending no ping value", pingField)
+		}
+		query.Set("msg", msg)
+		pushURL.RawQuery = query.Encode()
+		fullURL := pushURL.String()
 		logging.Infof("Final push URL: %s", fullURL)
 
 		// Perform HTTP push
-		resp, err := http.Get(fullURL)
+		httpClient, err := newKumaHTTPClient(cfg)
 		if err != nil {
-			logging.Errorf("HTTP request failed: %v", err)
+			logging.Fatalf("Invalid TLS settings: %v", err)
+		}
+		retries, _ := cmd.Flags().GetInt("retries")
+		if err := pushWithRetry(httpClient, fullURL, retries); err != nil {
+			logging.Errorf("Push failed: %v", err)
 			os.Exit(1)
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != 200 {
-			body, _ := io.ReadAll(resp.Body)
-			logging.Errorf("Push failed: %d %s", resp.StatusCode, string(body))
-			os.Exit(1)
+		if keepState {
+			state := &pushState{Status: status, Value: value, PushedAt: time.Now().UTC()}
+			if err := state.save(statePath); err != nil {
+				logging.Warnf("Failed to save push state: %v", err)
+			}
 		}
 
-		logging.Infof("PUSH SUCCESS: %s → %.1f%% (%s)", monitorName, value, status)
+		logging.Infof("PUSH SUCCESS: %s → %s (%s)", monitorName, formatValue(value, unit, 1), status)
 		os.Exit(0)
 	},
 }
+
+// findPushMonitor returns the push monitor with name in group, with the same metric,
+// field and threshold defaults as the Telegraf config.
+func findPushMonitor(cfg *config.Config, name, group string) (config.MonitorConfig, bool) {
+	for _, m := range cfg.GetAllMonitors() {
+		if m.Type == "push" && m.Name == name && m.Group == group {
+			m.ResolveMetrics(cfg)
+			return m, true
+		}
+	}
+	return config.MonitorConfig{}, false
+}
+
+// evaluatePush judges value for monitor m and returns the push status ("up" or "down")
+// and message. A min/max range wins over threshold and operator, which must be one of
+// operatorSymbols. With a recovery_threshold, a monitor whose previous status was down
+// stays down until value is back past it.
+func evaluatePush(m *config.MonitorConfig, value, threshold float64, operator, unit, previous string) (string, string) {
+	shown := formatValue(value, unit, 2)
+
+	if m.HasRange() {
+		switch {
+		case m.MinThreshold != nil && value < *m.MinThreshold:
+			return "down", fmt.Sprintf("%s: %s (below minimum %s)", m.Name, shown, formatValue(*m.MinThreshold, unit, -1))
+		case m.MaxThreshold != nil && value > *m.MaxThreshold:
+			return "down", fmt.Sprintf("%s: %s (above maximum %s)", m.Name, shown, formatValue(*m.MaxThreshold, unit, -1))
+		}
+		return "up", fmt.Sprintf("%s: %s (range %s)", m.Name, shown, describeRange(m, unit))
+	}
+
+	status := "up"
+	if breachesThreshold(operator, value, threshold) {
+		status = "down"
+	}
+	if m.RecoveryThreshold == nil {
+		return status, fmt.Sprintf("%s: %s (threshold %s %s)", m.Name, shown, operatorSymbols[operator], formatValue(threshold, unit, -1))
+	}
+
+	recovery := recoveryOperator(operator)
+	if status == "up" && previous == "down" && !breachesThreshold(recovery, value, *m.RecoveryThreshold) {
+		status = "down"
+	}
+	msg := fmt.Sprintf("%s: %s (threshold %s %s, recovery %s %s)", m.Name, shown, operatorSymbols[operator], formatValue(threshold, unit, -1),
+		operatorSymbols[recovery], formatValue(*m.RecoveryThreshold, unit, -1))
+	return status, msg
+}
+
+// recoveryOperator returns the comparison a down monitor's value must pass against its
+// recovery_threshold to report up again: strictly back on the healthy side.
+func recoveryOperator(operator string) string {
+	if operator == "lt" || operator == "lte" {
+		return "gt"
+	}
+	return "lt"
+}
+
+// unchangedPush reports whether pushing status and value again can be skipped: the last
+// push had the same status, a value within epsilon, and was recent enough that Uptime Kuma
+// won't miss a heartbeat, i.e. less than half the monitor interval ago.
+func unchangedPush(last *pushState, status string, value, epsilon float64, interval time.Duration) bool {
+	return last != nil && last.Status == status && math.Abs(value-last.Value) <= epsilon &&
+		time.Since(last.PushedAt) < interval/2
+}
+
+// heartbeatInterval returns the interval Uptime Kuma expects pushes within.
+func heartbeatInterval(cfg *config.Config) time.Duration {
+	if cfg.Interval > 0 {
+		return time.Duration(cfg.Interval) * time.Second
+	}
+	return defaultHeartbeatInterval
+}
+
+// defaultHeartbeatInterval is the Uptime Kuma monitor interval used without interval.
+const defaultHeartbeatInterval = 60 * time.Second
+
+// describeRange renders the monitor's inclusive range, e.g. "5..500", ">= 5" or "<= 500".
+func describeRange(m *config.MonitorConfig, unit string) string {
+	switch {
+	case m.MinThreshold != nil && m.MaxThreshold != nil:
+		return formatValue(*m.MinThreshold, unit, -1) + ".." + formatValue(*m.MaxThreshold, unit, -1)
+	case m.MinThreshold != nil:
+		return ">= " + formatValue(*m.MinThreshold, unit, -1)
+	default:
+		return "<= " + formatValue(*m.MaxThreshold, unit, -1)
+	}
+}
+
+// operatorSymbols maps the config operators to the symbol shown in push messages.
+var operatorSymbols = map[string]string{
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+	"eq":  "==",
+}
+
+// formatValue renders v with prec decimals (-1 for the shortest exact form) followed by
+// unit, e.g. "42.50%" or "1.5" for a load average.
+func formatValue(v float64, unit string, prec int) string {
+	return strconv.FormatFloat(v, 'f', prec, 64) + unit
+}
+
+// breachesThreshold reports whether value compared to threshold with operator means the
+// monitor is down.
+func breachesThreshold(operator string, value, threshold float64) bool {
+	switch operator {
+	case "gte":
+		return value >= threshold
+	case "lt":
+		return value < threshold
+	case "lte":
+		return value <= threshold
+	case "eq":
+		return value == threshold
+	default:
+		return value > threshold
+	}
+}
+
+// findLineProtocolField returns every numeric value of field in the line protocol lines
+// carrying all of tags, in input order. Unparsable lines are skipped; a non-numeric field
+// value is an error.
+func findLineProtocolField(lines []string, field string, tags map[string]string) ([]float64, error) {
+	var values []float64
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// Parse the line protocol point and look up the field by exact name
+		point, err := parseLineProtocol(line)
+		if err != nil {
+			logging.Warnf("Skipping unparsable line %d: %v", i+1, err)
+			continue
+		}
+
+		if !matchTags(point.Tags, tags) {
+			continue
+		}
+		valStr, ok := point.Fields[field]
+		if !ok {
+			continue
+		}
+
+		v, err := parseFieldValue(valStr)
+		if errors.Is(err, errNonNumericField) {
+			return nil, fmt.Errorf("field '%s' cannot be compared to a threshold: %w", field, err)
+		}
+		if err != nil {
+			logging.Errorf("PARSE FAILED for field '%s': raw value %q → error: %v", field, valStr, err)
+			continue
+		}
+
+		values = append(values, v)
+		logging.Debugf("PARSED %.6f from field '%s' (raw value: %q)", v, field, valStr)
+	}
+
+	return values, nil
+}
+
+// parseTagFilters turns --tag key=value arguments into a tag filter.
+func parseTagFilters(args []string) (map[string]string, error) {
+	tags := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("expected key=value, got %q", arg)
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// matchTags reports whether a series' tags include every key/value in want.
+func matchTags(have, want map[string]string) bool {
+	for key, value := range want {
+		if have[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// aggregateValues reduces the matched field values according to aggregation
+// (last, avg, max, min or sum). values must not be empty.
+func aggregateValues(aggregation string, values []float64) float64 {
+	result := values[0]
+	switch aggregation {
+	case "avg", "sum":
+		result = 0
+		for _, v := range values {
+			result += v
+		}
+		if aggregation == "avg" {
+			result /= float64(len(values))
+		}
+	case "max":
+		for _, v := range values[1:] {
+			result = math.Max(result, v)
+		}
+	case "min":
+		for _, v := range values[1:] {
+			result = math.Min(result, v)
+		}
+	default:
+		result = values[len(values)-1]
+	}
+	return result
+}
+
+const (
+	pushTimeout        = 10 * time.Second
+	pushInitialBackoff = 500 * time.Millisecond
+)
+
+// pushWithRetry sends the push request, retrying connection errors and 5xx responses up
+// to retries times with exponential backoff. Other non-200 responses fail immediately.
+func pushWithRetry(client *http.Client, pushURL string, retries int) error {
+	backoff := pushInitialBackoff
+
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Get(pushURL)
+		if err == nil {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+			err = fmt.Errorf("%d %s", resp.StatusCode, strings.TrimSpace(string(body)))
+			if resp.StatusCode < 500 {
+				return err // retrying will not fix a rejected push
+			}
+		}
+
+		if attempt >= retries {
+			return fmt.Errorf("giving up after %d attempt(s): %w", attempt+1, err)
+		}
+		logging.Warnf("Push attempt %d/%d failed: %v; retrying in %s", attempt+1, retries+1, err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+const statusScriptTimeout = 10 * time.Second
+
+// statusScriptInput is the JSON document written to a status script's stdin.
+type statusScriptInput struct {
+	Monitor   string             `json:"monitor"`
+	Group     string             `json:"group"`
+	Threshold float64            `json:"threshold"`
+	Fields    map[string]float64 `json:"fields"`
+}
+
+// runStatusScript executes a monitor's status script. Exit code 0 means "up", any other
+// exit code means "down"; the first line of stdout (if any) replaces the push message.
+func runStatusScript(script string, input statusScriptInput) (string, string, error) {
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return "", "", fmt.Errorf("encode script input: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), statusScriptTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, script)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	status := "up"
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || ctx.Err() != nil {
+			return "", "", fmt.Errorf("run script: %w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+		}
+		status = "down"
+	}
+
+	msg := strings.TrimSpace(strings.SplitN(stdout.String(), "\n", 2)[0])
+	return status, msg, nil
+}
+
+// buildPushURL joins the Uptime Kuma base URL with the push API path for token. Parsing the
+// base keeps IPv6 literals ("http://[::1]:3001"), ports and sub-path installs intact.
+func buildPushURL(baseURL, token string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("expected an absolute URL like https://uptime.example.com")
+	}
+
+	u = u.JoinPath("api", "push", token)
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u, nil
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+)
+
+func TestZeroThresholdIsKept(t *testing.T) {
+	cpu, def := 80.0, 90.0
+	cfg := &config.Config{GlobalThresholds: config.ThresholdConfig{CPU: &cpu, Default: &def}}
+
+	tests := []struct {
+		threshold float64
+		operator  string
+		value     float64
+		want      string
+	}{
+		{0, "gt", 0.5, "down"},
+		{0, "gt", 0, "up"},
+		{0, "gte", 0, "down"},
+		{0, "lt", -1, "down"},
+		{-10, "lt", -5, "up"},
+		{-10, "lt", -15, "down"},
+	}
+
+	for _, tt := range tests {
+		threshold := tt.threshold
+		m := &config.MonitorConfig{Name: "CPU", Type: "push", Threshold: &threshold, Operator: tt.operator}
+		m.ResolveMetrics(cfg)
+		if m.Threshold == nil || *m.Threshold != tt.threshold {
+			t.Fatalf("threshold %v after ResolveMetrics = %v, want it kept", tt.threshold, m.Threshold)
+		}
+
+		status, _ := evaluatePush(m, tt.value, *m.Threshold, tt.operator, "%", "")
+		if status != tt.want {
+			t.Errorf("value %v %s threshold %v: status = %s, want %s", tt.value, tt.operator, tt.threshold, status, tt.want)
+		}
+	}
+
+	unset := &config.MonitorConfig{Name: "CPU", Type: "push"}
+	unset.ResolveMetrics(cfg)
+	if unset.Threshold == nil || *unset.Threshold != 80 {
+		t.Errorf("unset threshold resolved to %v, want the global cpu threshold 80", unset.Threshold)
+	}
+}
+
+func TestParseTagFilters(t *testing.T) {
+	tags, err := parseTagFilters([]string{"container_name=web", "path=/", "empty="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"container_name": "web", "path": "/", "empty": ""}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("parseTagFilters = %v, want %v", tags, want)
+	}
+
+	for _, arg := range []string{"container_name", "=web"} {
+		if _, err := parseTagFilters([]string{arg}); err == nil {
+			t.Errorf("parseTagFilters(%q) succeeded, want an error", arg)
+		}
+	}
+}
+
+func TestFindLineProtocolFieldContainerFilter(t *testing.T) {
+	lines := []string{
+		"docker_container_cpu,container_name=db,host=h1 usage_percent=80 1700000000000000000",
+		"docker_container_cpu,container_name=web,host=h1 usage_percent=12.5 1700000000000000000",
+		"docker_container_cpu,container_name=web-2,host=h1 usage_percent=99 1700000000000000000",
+		"docker_container_cpu,host=h1 usage_percent=50 1700000000000000000",
+		"docker_container_cpu,container_name=web,host=h1 usage_percent=20 1700000001000000000",
+	}
+
+	tests := []struct {
+		filter []string
+		want   []float64
+	}{
+		{[]string{"container_name=web"}, []float64{12.5, 20}},
+		{[]string{"container_name=db", "host=h1"}, []float64{80}},
+		{[]string{"container_name=cache"}, nil},
+		{nil, []float64{80, 12.5, 99, 50, 20}},
+	}
+	for _, tt := range tests {
+		tags, err := parseTagFilters(tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := findLineProtocolField(lines, "usage_percent", tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filter %v: values = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestBuildPushURL(t *testing.T) {
+	tests := []struct {
+		base, want string
+	}{
+		{"http://kuma:3001", "http://kuma:3001/api/push/abc"},
+		{"http://kuma:3001/", "http://kuma:3001/api/push/abc"},
+		{"http://kuma:3001//", "http://kuma:3001/api/push/abc"},
+		{"  https://kuma.example.com  ", "https://kuma.example.com/api/push/abc"},
+		{"http://[::1]:3001/", "http://[::1]:3001/api/push/abc"},
+		{"https://host/kuma/", "https://host/kuma/api/push/abc"},
+		{"https://host/kuma", "https://host/kuma/api/push/abc"},
+		{"https://host/kuma/?x=1#top", "https://host/kuma/api/push/abc"},
+	}
+	for _, tt := range tests {
+		u, err := buildPushURL(tt.base, "abc")
+		if err != nil {
+			t.Errorf("buildPushURL(%q): %v", tt.base, err)
+			continue
+		}
+		if got := u.String(); got != tt.want {
+			t.Errorf("buildPushURL(%q) = %s, want %s", tt.base, got, tt.want)
+		}
+	}
+
+	for _, base := range []string{"", "kuma:3001", "/api", "http://"} {
+		if u, err := buildPushURL(base, "abc"); err == nil {
+			t.Errorf("buildPushURL(%q) = %s, want an error for a URL without scheme and host", base, u)
+		}
+	}
+}
+
+func TestBreachesThresholdBoundaries(t *testing.T) {
+	const threshold = 80.0
+	below, above := threshold-0.001, threshold+0.001
+
+	tests := []struct {
+		operator         string
+		below, at, above bool
+	}{
+		{"gt", false, false, true},
+		{"gte", false, true, true},
+		{"lt", true, false, false},
+		{"lte", true, true, false},
+		{"eq", false, true, false},
+		{"", false, false, true}, // gt is the default
+	}
+	for _, tt := range tests {
+		for _, c := range []struct {
+			value float64
+			want  bool
+		}{{below, tt.below}, {threshold, tt.at}, {above, tt.above}} {
+			if got := breachesThreshold(tt.operator, c.value, threshold); got != c.want {
+				t.Errorf("breachesThreshold(%q, %v, %v) = %v, want %v", tt.operator, c.value, threshold, got, c.want)
+			}
+		}
+	}
+}
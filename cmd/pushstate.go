@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/util"
+)
+
+// pushStateDir holds the last push of every monitor, one file each so concurrent
+// push-metric runs don't overwrite each other. It lives in the internal log directory by
+// default, the one writable volume of the generated Telegraf exec command.
+const pushStateDir = "push-state"
+
+// pushState records the last status push-metric sent for a monitor.
+type pushState struct {
+	Status   string    `json:"status"`
+	Value    float64   `json:"value"`
+	PushedAt time.Time `json:"pushed_at"`
+}
+
+// pushStatePath returns the state file of the monitor name in group under dir.
+func pushStatePath(dir, group, name string) string {
+	return filepath.Join(dir, url.PathEscape(group), url.PathEscape(name)+".json")
+}
+
+// loadPushState reads the state at path, returning nil when nothing was pushed yet.
+func loadPushState(path string) (*pushState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read push state: %w", err)
+	}
+
+	var state pushState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse push state %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// save writes the state atomically (temp file + rename), creating its directory.
+func (s *pushState) save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create push state directory: %w", err)
+	}
+
+	if err := util.WriteFileAtomic(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write push state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+)
+
+var (
+	configFetchTimeout time.Duration
+	configHeaders      []string
+	configRefresh      time.Duration
+)
+
+// remoteConfigDir is where a --config URL is mirrored without --config-dir. It is the
+// directory the generated Telegraf exec mounts, so push-metric reads the same config.
+const remoteConfigDir = "/config"
+
+// fetchRemoteConfig mirrors the --config URL into mergeDir, reporting whether any file
+// changed. For a local config it does nothing.
+func fetchRemoteConfig() (bool, error) {
+	if !config.IsRemote(configPath) {
+		return false, nil
+	}
+
+	header := make(http.Header)
+	for _, h := range configHeaders {
+		name, value, ok := strings.Cut(h, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return false, errors.New("invalid --config-header: want \"Name: value\"") // not echoed, it may hold a secret
+		}
+		// Expanded here so secrets can stay in the environment rather than the command line
+		header.Add(name, os.ExpandEnv(strings.TrimSpace(value)))
+	}
+
+	tlsCfg, err := tlsConfig(&config.Config{}) // --ca-cert and --insecure; the config isn't loaded yet
+	if err != nil {
+		return false, err
+	}
+	client := &http.Client{
+		Timeout:   configFetchTimeout,
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsCfg},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), configFetchTimeout)
+	defer cancel()
+	return config.FetchRemote(ctx, client, configPath, header, mergeDir())
+}
+
+// loadMergedConfig loads the merged config from mergeDir, fetching it first when --config
+// is a URL.
+func loadMergedConfig() (*config.Config, error) {
+	if _, err := fetchRemoteConfig(); err != nil {
+		return nil, err
+	}
+	return config.LoadMergedConfig(mergeDir())
+}
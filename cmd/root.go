@@ -2,8 +2,9 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -18,51 +19,270 @@ import (
 )
 
 var (
-	configPath   string
-	telegrafDir  = "/etc/telegraf/telegraf.d"
-	withTelegraf bool
+	configPath          string
+	configDir           string
+	telegrafDir         = "/etc/telegraf/telegraf.d"
+	withTelegraf        bool
+	prune               bool
+	dryRun              bool
+	force               bool
+	strictNotifications bool
+	runTimeout          time.Duration
+	connectTimeout      time.Duration
+	connectRetries      int
+	connectRetryWait    time.Duration
+	concurrency         int
+	outputFormat        string
 )
 
+// runCacheFile is written next to the config and records the last reconciled state.
+const runCacheFile = ".uptime-kuma-agent.cache"
+
 func NewRootCmd() *cobra.Command {
 	var rootCmd = &cobra.Command{
-		Use:   "uptime-kuma-agent",
-		Short: "Uptime Kuma provisioning agent",
+		Use:              "uptime-kuma-agent",
+		Short:            "Uptime Kuma provisioning agent",
+		PersistentPreRun: printVersionAndExit,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := run(); err != nil {
-				log.Fatal(err)
+				logging.Fatal(err)
 			}
 		},
 	}
 
-	rootCmd.PersistentFlags().StringVar(&configPath, "config", "/config/config.yaml", "path to config file")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "/config/config.yaml", "path to config file, or an http(s):// URL to fetch it from")
+	rootCmd.PersistentFlags().StringVar(&configDir, "config-dir", "", "directory to merge config.yaml and config.*.yaml files from (default: directory of --config; for a URL, /config, where it is mirrored)")
+	rootCmd.PersistentFlags().DurationVar(&configFetchTimeout, "config-timeout", 30*time.Second, "time limit for fetching a --config URL")
+	rootCmd.PersistentFlags().StringArrayVar(&configHeaders, "config-header", nil, "HTTP header sent when fetching a --config URL, as \"Name: value\" with ${VAR} expanded (repeatable)")
 	rootCmd.PersistentFlags().BoolVar(&withTelegraf, "with-telegraf", true, "generate Telegraf configuration files")
 	rootCmd.PersistentFlags().StringVar(&telegrafDir, "telegraf-dir", "/telegraf.d", "Directory to write Telegraf drop-in configs")
+	rootCmd.PersistentFlags().StringVar(&caCertPath, "ca-cert", "", "PEM CA bundle to trust for HTTPS connections to Uptime Kuma (overrides ca_cert)")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "insecure", false, "skip TLS certificate verification (lab setups only)")
+	rootCmd.PersistentFlags().StringVar(&logging.LevelOverride, "log-level", "", "log level: debug, info, warn, error (overrides UPTIME_KUMA_AGENT_LOG_LEVEL and logging.level)")
+	rootCmd.PersistentFlags().BoolVar(&showVersion, "version", false, "print version and build information")
+	addProvisionFlags(rootCmd)
+	rootCmd.Flags().BoolVar(&watch, "watch", false, "keep running and reconcile again whenever a config file changes")
+	rootCmd.Flags().StringVar(&healthAddr, "health-addr", "", "with --watch, serve /healthz and /readyz on this address (e.g. :8080)")
+	rootCmd.Flags().DurationVar(&configRefresh, "config-refresh", time.Minute, "with --watch and a --config URL, fetch it again at this interval")
+	rootCmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "with --watch, serve Prometheus metrics on /metrics at this address (e.g. :9090)")
 
 	// Add push-metric subcommand
 	rootCmd.AddCommand(pushMetricCmd)
 	pushMetricCmd.Flags().String("monitor", "", "Monitor name")
 	pushMetricCmd.Flags().String("group", "", "Monitor group name (optional)")
 	pushMetricCmd.Flags().String("token", "", "Push token")
+	pushMetricCmd.Flags().String("spec", "", "Monitor settings as JSON, written by the Telegraf generator (default: looked up in the config)")
+	pushMetricCmd.Flags().String("field", "", "Field compared to the threshold (overrides the spec and config)")
+	pushMetricCmd.Flags().Float64("threshold", 0, "Threshold the field is compared to (overrides the spec and config)")
+	pushMetricCmd.Flags().String("operator", "", "Comparison that means down: gt, gte, lt, lte or eq (overrides the spec and config)")
+	pushMetricCmd.Flags().String("unit", "", "Unit shown in the push message, \"\" for none (overrides the spec and config)")
+	pushMetricCmd.Flags().Int("retries", 3, "Retries for a failed push (connection errors and 5xx responses)")
+	pushMetricCmd.Flags().StringArray("tag", nil, "Only read series with this tag, as key=value (repeatable)")
+	pushMetricCmd.Flags().String("state-dir", "", "Directory for the last push of each monitor (default: push-state under the internal log directory)")
+	pushMetricCmd.Flags().Bool("always-push", false, "Push even when status and value are unchanged (ignores push_epsilon)")
 	pushMetricCmd.MarkFlagRequired("monitor")
 	pushMetricCmd.MarkFlagRequired("token")
 
+	// Add provision and generate-telegraf subcommands, each one half of the root command
+	rootCmd.AddCommand(provisionCmd)
+	addProvisionFlags(provisionCmd)
+	rootCmd.AddCommand(generateTelegrafCmd)
+	generateTelegrafCmd.Flags().BoolVar(&dryRun, "dry-run", false, "log the files that would be written without writing them")
+
+	// Add test-push subcommand
+	rootCmd.AddCommand(testPushCmd)
+	testPushCmd.Flags().String("monitor", "", "Monitor name")
+	testPushCmd.Flags().String("group", "", "Monitor group name (optional)")
+	testPushCmd.Flags().String("token", "", "Push token (default: the monitor's token from tokens.yaml)")
+	testPushCmd.Flags().Float64("value", 0, "Value to evaluate against the monitor's threshold and push")
+	testPushCmd.Flags().Int("retries", 0, "Retries for a failed push (connection errors and 5xx responses)")
+	testPushCmd.MarkFlagRequired("monitor")
+	testPushCmd.MarkFlagRequired("value")
+
+	// Add rotate-token subcommand
+	rootCmd.AddCommand(rotateTokenCmd)
+	rotateTokenCmd.Flags().String("monitor", "", "Push monitor name")
+	rotateTokenCmd.Flags().String("group", "", "Monitor group name (optional)")
+	rotateTokenCmd.MarkFlagRequired("monitor")
+
+	// Add status subcommand
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "print the monitors as JSON")
+
+	// Add validate subcommand
+	rootCmd.AddCommand(validateCmd)
+
+	// Add version subcommand
+	rootCmd.AddCommand(versionCmd)
+
 	return rootCmd
 }
 
+// addProvisionFlags registers the flags controlling a provisioning run on cmd.
+func addProvisionFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&prune, "prune", false, "delete monitors under provisioned groups that are no longer in config")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "log planned changes without applying them")
+	cmd.Flags().BoolVar(&force, "force", false, "provision even if config and Uptime Kuma state are unchanged since the last run")
+	cmd.Flags().BoolVar(&strictNotifications, "strict-notifications", false, "fail when a referenced notification name does not exist (same as agent.strict_notifications)")
+	cmd.Flags().StringVar(&pushgatewayURL, "pushgateway-url", "", "push run metrics to this Prometheus Pushgateway after each run")
+	cmd.Flags().StringVar(&outputFormat, "output-format", "text", "run summary format: text (log lines) or json (printed to stdout after each run)")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 4, "number of monitors created or updated at a time")
+	cmd.Flags().DurationVar(&runTimeout, "timeout", 60*time.Second, "time limit for a whole provisioning run")
+	cmd.Flags().DurationVar(&connectTimeout, "connect-timeout", 0, "time limit for connecting and logging in to Uptime Kuma (default: no limit within --timeout)")
+	cmd.Flags().IntVar(&connectRetries, "connect-retries", 5, "retries when connecting to Uptime Kuma fails, e.g. while it is still starting")
+	cmd.Flags().DurationVar(&connectRetryWait, "connect-retry-interval", time.Second, "wait before the first connection retry, doubled for each further retry (max 30s)")
+}
+
+// run provisions Uptime Kuma and then, unless --with-telegraf=false, regenerates the
+// Telegraf configs; with --watch it repeats that on every config change.
 func run() error {
-	cfg, err := config.LoadMergedConfig(filepath.Dir(configPath))
+	if err := checkOutputFormat(); err != nil {
+		return err
+	}
+	if watch {
+		return runWatch()
+	}
+
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
+	if healthAddr != "" || metricsAddr != "" {
+		logging.Warn("--health-addr and --metrics-addr only apply with --watch, ignoring them (use --pushgateway-url for one-shot runs)")
+	}
+
+	if err := runProvision(cfg); err != nil {
+		return err
+	}
+
+	if withTelegraf {
+		logging.Infof("withTelegraf flag: %t - generating configs", withTelegraf)
+		if err := telegraf.GenerateTelegrafConfigs(cfg, telegrafDir, dryRun); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeDir returns the directory the config files are merged from: --config-dir when
+// set, otherwise the directory of --config, or remoteConfigDir when it is a URL.
+func mergeDir() string {
+	if configDir != "" {
+		return configDir
+	}
+	if config.IsRemote(configPath) {
+		return remoteConfigDir
+	}
+	return filepath.Dir(configPath)
+}
+
+// loadConfig loads the merged config and initializes logging from it.
+func loadConfig() (*config.Config, error) {
+	cfg, err := loadMergedConfig()
+	if err != nil {
+		return nil, err
+	}
 
 	// Initialize logger
 	if err := logging.InitLogger(&cfg.Agent.Logging); err != nil {
-		return fmt.Errorf("failed to initialize logger: %w", err)
+		return nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	logging.Info(cfg.Summary())
+	if config.IsRemote(configPath) {
+		logging.Infof("Config fetched from %s into %s", config.RedactedURL(configPath), mergeDir())
+	}
+	logging.Debugf("Config files merged: %v", cfg.SourceFiles)
+	for _, m := range cfg.Migrations() {
+		logging.Infof("Config migrated: %s", m)
+	}
+
+	warnings, err := cfg.CheckMetrics()
+	for _, w := range warnings {
+		logging.Warnf("Warning: %s", w)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// runProvision creates and updates the configured notifications, groups and monitors.
+func runProvision(cfg *config.Config) error {
+	// Hash before provisioning, which fills in defaults on the loaded config
+	opts := provision.Options{Prune: prune, DryRun: dryRun, StrictNotifications: strictNotifications, Concurrency: concurrency}
+	configHash, err := provision.ConfigHash(cfg, opts)
+	if err != nil {
+		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
 	defer cancel()
 
+	// Name the time limit when it is what ended the run, so it isn't read as a network problem
+	explainTimeout := func(err error) error {
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("provisioning run exceeded --timeout %s: %w", runTimeout, err)
+		}
+		return err
+	}
+
+	// Finish the run report and deliver it to the post-run webhook, if configured
+	report := provision.NewRunReport()
+	report.DryRun = dryRun
+	finishRun := func(runErr error) error {
+		report.Finish(runErr)
+		observeRun(report)
+		if outputFormat == "json" {
+			printReport(report)
+		}
+		webhook := cfg.Agent.PostRunWebhook
+		if dryRun && webhook != nil {
+			logging.Info("DRY RUN: skipping post-run webhook")
+		} else if webhook != nil && (runErr == nil || webhook.OnFailure) {
+			postRunWebhook(webhook, report)
+		}
+		return runErr
+	}
+
+	// The kuma client has no TLS options, so provisioning always uses the system trust store
+	if insecure || effectiveCACert(cfg) != "" {
+		logging.Warn("--ca-cert/--insecure apply to push-metric only; provisioning verifies Uptime Kuma against the system trust store")
+	}
+
+	client, err := newKumaClient(ctx, cfg)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && connectTimeout > 0 && ctx.Err() == nil {
+			err = fmt.Errorf("connecting exceeded --connect-timeout %s: %w", connectTimeout, err)
+		}
+		return finishRun(explainTimeout(fmt.Errorf("failed to create client: %w", err)))
+	}
+	logging.Info("Client created successfully")
+	defer disconnect(client)
+
+	cachePath := filepath.Join(mergeDir(), runCacheFile)
+	if !force && !dryRun && runIsCached(ctx, client, cachePath, configHash) {
+		logging.Info("No changes since last run, skipping provisioning (use --force to override)")
+		report.Skipped = true
+		finishRun(nil)
+	} else {
+		if err := finishRun(explainTimeout(provision.ProvisionKumaMonitor(ctx, client, cfg, opts, report))); err != nil {
+			return err
+		}
+		logging.Infof("Provisioning completed successfully (created=%d, updated=%d, unchanged=%d, deleted=%d, errors=%d)",
+			report.Created, report.Updated, report.Unchanged, report.Deleted, len(report.Errors))
+
+		if !dryRun && len(report.Errors) == 0 {
+			saveRunCache(ctx, client, cachePath, opts)
+		}
+	}
+
+	return nil
+}
+
+// newKumaClient connects and logs in to Uptime Kuma with the Socket.IO log level from
+// config, retrying with backoff up to --connect-retries times.
+func newKumaClient(ctx context.Context, cfg *config.Config) (*kuma.Client, error) {
 	// Determine Socket.IO log level from config
 	socketIOLogLevel := logging.GetSocketIOLogLevel(&cfg.Agent.Logging)
 	var kumaLogLevel int
@@ -82,27 +302,64 @@ func run() error {
 		kumaLogLevel = kuma.LogLevel("warn")
 	}
 
-	client, err := kuma.New(ctx, cfg.UptimeKumaURL, cfg.Username, cfg.Password, kuma.WithLogLevel(kumaLogLevel))
-	if err != nil {
-		return fmt.Errorf("failed to create client: %w", err)
+	opts := []kuma.Option{kuma.WithLogLevel(kumaLogLevel)}
+	if connectTimeout > 0 {
+		opts = append(opts, kuma.WithConnectTimeout(connectTimeout))
 	}
-	logging.Info("Client created successfully")
-	defer client.Disconnect()
 
-	if err := provision.ProvisionKumaMonitor(ctx, client, cfg); err != nil {
-		return err
-	}
-	logging.Info("Provisioning completed successfully")
+	wait := connectRetryWait
+	for attempt := 1; ; attempt++ {
+		client, err := kuma.New(ctx, cfg.UptimeKumaURL, cfg.Username, cfg.Password, opts...)
+		if err == nil || attempt > connectRetries || ctx.Err() != nil {
+			return client, err
+		}
 
-	if withTelegraf {
-		logging.Infof("withTelegraf flag: %t - generating configs", withTelegraf)
-		if err := telegraf.GenerateTelegrafConfigs(cfg, telegrafDir); err != nil {
-			return err
+		logging.Warnf("Connecting to Uptime Kuma failed (attempt %d of %d): %v; retrying in %s", attempt, connectRetries+1, err, wait)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, err
 		}
+		wait = min(2*wait, maxConnectRetryWait)
 	}
+}
 
-	// Force immediate exit to avoid hanging on Socket.IO goroutines
-	os.Exit(0)
+// maxConnectRetryWait caps the doubling wait between connection retries.
+const maxConnectRetryWait = 30 * time.Second
 
+// disconnectTimeout bounds how long closing the Uptime Kuma connection may block.
+const disconnectTimeout = 5 * time.Second
+
+// disconnect closes the Socket.IO connection. The socket library can hang while its
+// goroutines drain, so after disconnectTimeout the connection is abandoned with a
+// warning rather than blocking the caller.
+func disconnect(client *kuma.Client) {
+	done := make(chan error, 1)
+	go func() { done <- client.Disconnect() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			logging.Debugf("Closing Uptime Kuma connection: %v", err)
+		}
+	case <-time.After(disconnectTimeout):
+		logging.Warnf("Warning: Uptime Kuma connection did not close within %s, abandoning it", disconnectTimeout)
+	}
+}
+
+// checkOutputFormat rejects an unknown --output-format before the config is loaded, while
+// errors still reach the terminal rather than the log file.
+func checkOutputFormat() error {
+	if outputFormat != "text" && outputFormat != "json" {
+		return fmt.Errorf("invalid --output-format %q (expected text or json)", outputFormat)
+	}
 	return nil
 }
+
+// printReport writes report to stdout for --output-format json. It is a single line, so
+// a --watch session prints one parseable summary per run.
+func printReport(report *provision.RunReport) {
+	if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+		logging.Errorf("Failed to write run summary: %v", err)
+	}
+}
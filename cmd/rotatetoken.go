@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+	"github.com/gitisz/uptime-kuma-agent/internal/provision"
+	"github.com/gitisz/uptime-kuma-agent/internal/telegraf"
+	"github.com/spf13/cobra"
+)
+
+var rotateTokenCmd = &cobra.Command{
+	Use:   "rotate-token",
+	Short: "Give a push monitor a new push token and update tokens.yaml and Telegraf",
+	Run: func(cmd *cobra.Command, args []string) {
+		monitorName, _ := cmd.Flags().GetString("monitor")
+		groupName, _ := cmd.Flags().GetString("group")
+
+		cfg, err := loadConfig()
+		if err != nil {
+			logging.Fatal(err)
+		}
+		mcfg := cfg.FindPushMonitor(monitorName, groupName)
+		if mcfg == nil {
+			logging.Fatalf("No push monitor %q in group %q", monitorName, groupName)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), statusTimeout)
+		defer cancel()
+
+		client, err := newKumaClient(ctx, cfg)
+		if err != nil {
+			logging.Fatalf("Failed to create client: %v", err)
+		}
+		oldToken, err := provision.RotatePushToken(ctx, client, cfg, mcfg)
+		disconnect(client)
+		if err != nil {
+			logging.Fatalf("Failed to rotate push token of %s: %v", monitorName, err)
+		}
+
+		// The new token is live from here on; a provisioning run fetches it again if saving fails
+		if _, err := config.SaveTokens(cfg); err != nil {
+			logging.Fatalf("Push token rotated in Uptime Kuma but not saved (run provision to fetch it): %v", err)
+		}
+		logging.Infof("Saved push tokens to %s", cfg.TokensPath())
+
+		if withTelegraf {
+			if err := telegraf.GenerateTelegrafConfigs(cfg, telegrafDir, false); err != nil {
+				logging.Fatalf("Failed to generate Telegraf configs: %v", err)
+			}
+		}
+
+		if oldToken == "" {
+			return
+		}
+		accepted, err := pushTokenAccepted(cfg, oldToken)
+		switch {
+		case err != nil:
+			logging.Warnf("Could not confirm the old push token is rejected: %v", err)
+		case accepted:
+			logging.Fatalf("The old push token of %s is still accepted by Uptime Kuma", monitorName)
+		default:
+			logging.Infof("The old push token of %s is rejected by Uptime Kuma", monitorName)
+		}
+	},
+}
+
+// pushTokenAccepted reports whether Uptime Kuma still takes pushes for token. The probe
+// pushes "up", which is only recorded if the token does still work.
+func pushTokenAccepted(cfg *config.Config, token string) (bool, error) {
+	pushURL, err := buildPushURL(cfg.UptimeKumaURL, token)
+	if err != nil {
+		return false, err
+	}
+	query := url.Values{}
+	query.Set("status", "up")
+	query.Set("msg", "push token rotation check")
+	pushURL.RawQuery = query.Encode()
+
+	httpClient, err := newKumaHTTPClient(cfg)
+	if err != nil {
+		return false, err
+	}
+	resp, err := httpClient.Get(pushURL.String())
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound: // Uptime Kuma's answer for tokens no active monitor has
+		return false, nil
+	}
+	return false, fmt.Errorf("unexpected response %s", resp.Status)
+}
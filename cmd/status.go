@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+	"github.com/prometheus/common/expfmt"
+	"github.com/spf13/cobra"
+)
+
+// statusTimeout bounds connecting to Uptime Kuma and fetching monitor states.
+const statusTimeout = 30 * time.Second
+
+// heartbeatStatuses maps Uptime Kuma's monitor_status metric values to names.
+var heartbeatStatuses = map[float64]string{0: "down", 1: "up", 2: "pending", 3: "maintenance"}
+
+// monitorStatus is one row of the status report.
+type monitorStatus struct {
+	Name   string `json:"name"`
+	Group  string `json:"group,omitempty"`
+	Type   string `json:"type"`
+	Active bool   `json:"active"`
+	Status string `json:"status"` // last heartbeat: up, down, pending, maintenance or unknown
+}
+
+var statusJSON bool
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the current state of the provisioned monitors without changing anything",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig()
+		if err != nil {
+			logging.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), statusTimeout)
+		defer cancel()
+
+		rows, err := collectStatus(ctx, cfg)
+		if err != nil {
+			logging.Fatal(err)
+		}
+
+		if statusJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(rows); err != nil {
+				logging.Fatal(err)
+			}
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tGROUP\tTYPE\tACTIVE\tSTATUS")
+		for _, r := range rows {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", r.Name, r.Group, r.Type, r.Active, r.Status)
+		}
+		w.Flush()
+	},
+}
+
+// collectStatus lists the monitors under the configured groups, plus configured monitors
+// without a group, with their active flag and last heartbeat status.
+func collectStatus(ctx context.Context, cfg *config.Config) ([]monitorStatus, error) {
+	client, err := newKumaClient(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	defer disconnect(client)
+
+	monitors, err := client.GetMonitors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch monitors: %w", err)
+	}
+
+	configuredGroups := make(map[string]bool)
+	for _, g := range cfg.Groups {
+		configuredGroups[g.Name] = true
+	}
+	ungrouped := make(map[string]bool)
+	for _, m := range cfg.GetAllMonitors() {
+		if m.Group == "" {
+			ungrouped[m.Name] = true
+		}
+	}
+	groupNames := make(map[int64]string) // group monitor ID -> name
+	for _, m := range monitors {
+		if m.Type() == "group" && configuredGroups[m.Name] {
+			groupNames[m.ID] = m.Name
+		}
+	}
+
+	heartbeats, err := fetchHeartbeatStatus(ctx, cfg)
+	if err != nil {
+		logging.Warnf("Last heartbeat status unavailable: %v", err)
+	}
+
+	var rows []monitorStatus
+	for _, m := range monitors {
+		var group string
+		if m.Parent != nil {
+			group = groupNames[*m.Parent]
+		}
+		if m.Type() == "group" || (group == "" && (m.Parent != nil || !ungrouped[m.Name])) {
+			continue
+		}
+
+		status, ok := heartbeats[m.Name]
+		if !ok {
+			status = "unknown"
+		}
+		rows = append(rows, monitorStatus{Name: m.Name, Group: group, Type: m.Type(), Active: m.IsActive, Status: status})
+	}
+	return rows, nil
+}
+
+// fetchHeartbeatStatus reads the last heartbeat status per monitor name from Uptime Kuma's
+// Prometheus endpoint, which accepts the same username and password as the UI.
+func fetchHeartbeatStatus(ctx context.Context, cfg *config.Config) (map[string]string, error) {
+	u, err := url.Parse(cfg.UptimeKumaURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.JoinPath("metrics").String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(cfg.Username, cfg.Password)
+
+	httpClient, err := newKumaHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET /metrics: %s", resp.Status)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse /metrics: %w", err)
+	}
+
+	statuses := make(map[string]string)
+	family, ok := families["monitor_status"]
+	if !ok {
+		return statuses, nil
+	}
+	for _, metric := range family.GetMetric() {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == "monitor_name" {
+				if status, ok := heartbeatStatuses[metric.GetGauge().GetValue()]; ok {
+					statuses[label.GetValue()] = status
+				}
+			}
+		}
+	}
+	return statuses, nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+var testPushCmd = &cobra.Command{
+	Use:   "test-push",
+	Short: "Push a synthetic value to a push monitor to check its token and threshold",
+	Run: func(cmd *cobra.Command, args []string) {
+		monitorName, _ := cmd.Flags().GetString("monitor")
+		groupName, _ := cmd.Flags().GetString("group")
+		token, _ := cmd.Flags().GetString("token")
+		value, _ := cmd.Flags().GetFloat64("value")
+		retries, _ := cmd.Flags().GetInt("retries")
+
+		cfg, err := loadMergedConfig()
+		if err != nil {
+			logging.Fatalf("Invalid config: %v", err)
+		}
+
+		m, ok := findPushMonitor(cfg, monitorName, groupName)
+		if !ok {
+			logging.Fatalf("No push monitor %q in group %q", monitorName, groupName)
+		}
+		if token == "" {
+			token = m.PushToken // from tokens.yaml once provisioning has run
+		}
+		if token == "" {
+			logging.Fatalf("Monitor %q has no push token yet; provision it first or pass --token", monitorName)
+		}
+
+		operator := "gt"
+		if m.Operator != "" {
+			operator = strings.ToLower(m.Operator)
+		}
+		if _, ok := operatorSymbols[operator]; !ok {
+			logging.Fatalf("Unknown operator %q for monitor %q", operator, monitorName)
+		}
+		status, msg := evaluatePush(&m, value, *m.Threshold, operator, m.ValueUnit(), "")
+
+		pushURL, err := buildPushURL(cfg.UptimeKumaURL, token)
+		if err != nil {
+			logging.Fatalf("Invalid uptime_kuma_url %q: %v", cfg.UptimeKumaURL, err)
+		}
+		query := url.Values{}
+		query.Set("status", status)
+		query.Set("ping", strconv.FormatFloat(value, 'f', 2, 64))
+		query.Set("msg", msg)
+		pushURL.RawQuery = query.Encode()
+
+		httpClient, err := newKumaHTTPClient(cfg)
+		if err != nil {
+			logging.Fatalf("Invalid TLS settings: %v", err)
+		}
+		if err := pushWithRetry(httpClient, pushURL.String(), retries); err != nil {
+			logging.Fatalf("Push failed: %v", err)
+		}
+
+		fmt.Printf("Pushed %s: %s\n", status, msg)
+	},
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+)
+
+var (
+	caCertPath string
+	insecure   bool
+)
+
+// effectiveCACert returns the CA bundle path, preferring --ca-cert over the config.
+func effectiveCACert(cfg *config.Config) string {
+	if caCertPath != "" {
+		return caCertPath
+	}
+	return cfg.CACert
+}
+
+// tlsConfig builds the TLS settings for connections to Uptime Kuma: the system roots plus
+// the configured CA bundle, or no verification at all with --insecure.
+func tlsConfig(cfg *config.Config) (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if insecure {
+		tlsCfg.InsecureSkipVerify = true
+		return tlsCfg, nil
+	}
+
+	caFile := effectiveCACert(cfg)
+	if caFile == "" {
+		return tlsCfg, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", caFile)
+	}
+	tlsCfg.RootCAs = pool
+
+	return tlsCfg, nil
+}
+
+// newKumaHTTPClient returns an HTTP client for talking to Uptime Kuma with the
+// configured TLS settings.
+func newKumaHTTPClient(cfg *config.Config) (*http.Client, error) {
+	tlsCfg, err := tlsConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+	return &http.Client{Timeout: pushTimeout, Transport: transport}, nil
+}
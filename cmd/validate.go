@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the merged config for problems without connecting to Uptime Kuma",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadMergedConfig()
+		if err != nil {
+			logging.Fatalf("Invalid config: %v", err)
+		}
+
+		problems := cfg.Validate()
+		for _, problem := range problems {
+			fmt.Fprintln(os.Stderr, problem)
+		}
+		if len(problems) > 0 {
+			fmt.Fprintf(os.Stderr, "%d problem(s) found in %d config file(s)\n", len(problems), len(cfg.SourceFiles))
+			os.Exit(1)
+		}
+
+		fmt.Println(cfg.Summary())
+		fmt.Println("Config is valid")
+	},
+}
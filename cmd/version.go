@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/spf13/cobra"
+)
+
+// Build metadata, set at build time with
+// -ldflags "-X github.com/gitisz/uptime-kuma-agent/cmd.Version=... -X ...cmd.Commit=... -X ...cmd.BuildDate=..."
+var (
+	Version   = "dev"
+	Commit    = "none"
+	BuildDate = "unknown"
+)
+
+var showVersion bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version and build information",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(versionString())
+	},
+}
+
+func versionString() string {
+	return fmt.Sprintf("uptime-kuma-agent %s (commit %s, built %s, %s %s/%s)",
+		Version, Commit, BuildDate, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+// printVersionAndExit handles the persistent --version flag before any command runs.
+func printVersionAndExit(cmd *cobra.Command, args []string) {
+	if showVersion {
+		fmt.Println(versionString())
+		os.Exit(0)
+	}
+}
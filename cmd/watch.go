@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+	"github.com/gitisz/uptime-kuma-agent/internal/metrics"
+	"github.com/gitisz/uptime-kuma-agent/internal/telegraf"
+)
+
+var watch bool
+
+// watchDebounce is how long the config directory has to be quiet before a change is
+// reconciled, so editors that write in several steps trigger a single run.
+const watchDebounce = 2 * time.Second
+
+// runWatch reconciles once and then again after every change to a config file, until
+// the process is interrupted.
+func runWatch() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	dir := mergeDir()
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config directory: %w", err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch config directory %s: %w", dir, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	status := &runStatus{}
+	muxes := make(map[string]*http.ServeMux) // health and metrics may share an address
+	muxFor := func(addr string) *http.ServeMux {
+		if muxes[addr] == nil {
+			muxes[addr] = http.NewServeMux()
+		}
+		return muxes[addr]
+	}
+	if healthAddr != "" {
+		handleHealth(muxFor(healthAddr), status)
+	}
+	if metricsAddr != "" {
+		muxFor(metricsAddr).Handle("/metrics", metrics.Handler())
+	}
+	for addr, mux := range muxes {
+		server := serveHTTP(addr, mux)
+		defer server.Close()
+	}
+
+	status.record(reconcileConfig(cfg))
+	logging.Infof("Watching %s for config changes", dir)
+
+	// A remote config is fetched again periodically; changed files show up as watch events
+	var refresh <-chan time.Time
+	if config.IsRemote(configPath) && configRefresh > 0 {
+		ticker := time.NewTicker(configRefresh)
+		defer ticker.Stop()
+		refresh = ticker.C
+		logging.Infof("Fetching %s every %s", config.RedactedURL(configPath), configRefresh)
+	}
+
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logging.Info("Stopping config watch")
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !isConfigFile(event.Name) || event.Op == fsnotify.Chmod {
+				continue
+			}
+			logging.Debugf("Config change: %s", event)
+			debounce.Reset(watchDebounce)
+
+		case <-refresh:
+			if _, err := fetchRemoteConfig(); err != nil {
+				logging.Warnf("Warning: failed to fetch config, keeping the last copy: %v", err)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logging.Warnf("Warning: config watch error: %v", err)
+
+		case <-debounce.C:
+			// A half-written file fails to load; the write that completes it is another event
+			cfg, err := loadConfig()
+			if err != nil {
+				logging.Errorf("Not reconciling, config failed to load (waiting for the next change): %v", err)
+				continue
+			}
+			status.record(reconcileConfig(cfg))
+		}
+	}
+}
+
+// reconcileConfig runs provisioning and Telegraf generation for one watch iteration.
+// Failures are logged so the watch keeps going, and returned for the readiness status.
+func reconcileConfig(cfg *config.Config) error {
+	if err := runProvision(cfg); err != nil {
+		logging.Errorf("Provisioning failed: %v", err)
+		return err
+	}
+	if withTelegraf {
+		if err := telegraf.GenerateTelegrafConfigs(cfg, telegrafDir, dryRun); err != nil {
+			logging.Errorf("Failed to generate Telegraf configs: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// isConfigFile reports whether path is one of the files LoadMergedConfig reads.
+func isConfigFile(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasPrefix(name, "config.") && (strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".json"))
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+	"github.com/gitisz/uptime-kuma-agent/internal/provision"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// postRunWebhook POSTs the run report as JSON to the configured webhook.
+// Delivery failures are logged but never fail the run.
+func postRunWebhook(webhook *config.WebhookConfig, report *provision.RunReport) {
+	if webhook.URL == "" {
+		logging.Warn("Warning: post_run_webhook configured without url - skipping")
+		return
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		logging.Warnf("Warning: failed to encode run report for webhook: %v", err)
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		logging.Warnf("Warning: failed to build webhook request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range webhook.Headers {
+		req.Header.Set(name, value)
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		logging.Warnf("Warning: failed to deliver post-run webhook: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(resp.Body)
+		logging.Warnf("Warning: post-run webhook returned %d %s", resp.StatusCode, string(respBody))
+		return
+	}
+
+	logging.Infof("Delivered post-run webhook (status: %s)", report.Status)
+}
@@ -1,13 +1,19 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
-	"gopkg.in/yaml.v3"
+	"github.com/BurntSushi/toml"
 )
 
 type LoggingConfig struct {
@@ -20,76 +26,339 @@ type LoggingConfig struct {
 	MaxBackups           int    `yaml:"max_backups,omitempty"`            // max number of backup files
 	Compress             *bool  `yaml:"compress,omitempty"`               // compress rotated files
 	SocketIOLogLevel     string `yaml:"socketio_log_level,omitempty"`     // debug, info, warn, error, off
+	Output               string `yaml:"output,omitempty"`                 // file (default), stdout, syslog
+	SyslogNetwork        string `yaml:"syslog_network,omitempty"`         // udp, tcp; empty for the local syslog daemon
+	SyslogAddress        string `yaml:"syslog_address,omitempty"`         // host:port when syslog_network is set
+	SyslogFacility       string `yaml:"syslog_facility,omitempty"`        // daemon (default), user, local0-local7, ...
+	SyslogTag            string `yaml:"syslog_tag,omitempty"`             // default uptime-kuma-agent
 }
 
+// ThresholdConfig holds the global thresholds. Like a monitor's threshold they are
+// pointers, so 0 is a threshold of its own rather than "unset".
 type ThresholdConfig struct {
-	CPU  float64 `yaml:"cpu,omitempty"`
-	RAM  float64 `yaml:"ram,omitempty"`
-	Disk float64 `yaml:"disk,omitempty"`
+	CPU  *float64 `yaml:"cpu,omitempty"`
+	RAM  *float64 `yaml:"ram,omitempty"`
+	Disk *float64 `yaml:"disk,omitempty"`
+	// Default applies to other metrics, and to cpu/ram/disk when their value is unset
+	Default *float64 `yaml:"default,omitempty"`
+}
+
+// Built-in thresholds used when global_thresholds leaves a metric unset.
+const (
+	DefaultThreshold     = 90
+	DefaultDiskThreshold = 85
+)
+
+type WebhookConfig struct {
+	URL       string            `yaml:"url"`
+	Headers   map[string]string `yaml:"headers,omitempty"`
+	OnFailure bool              `yaml:"on_failure,omitempty"` // also POST the report when the run fails
 }
 
 type AgentConfig struct {
-	UseOutputsDiscard *bool         `yaml:"use_outputs_discard,omitempty"`
-	DockerImage       string        `yaml:"docker_image"`
-	Logging           LoggingConfig `yaml:"logging,omitempty"`
+	UseOutputsDiscard   *bool          `yaml:"use_outputs_discard,omitempty"`
+	DockerImage         string         `yaml:"docker_image"`
+	PinImageDigest      *bool          `yaml:"pin_image_digest,omitempty"` // run the exec image by the digest it resolves to at generation
+	ExecMode            string         `yaml:"exec_mode,omitempty"`        // docker (default) or binary: how the Telegraf exec runs push-metric
+	BinaryPath          string         `yaml:"binary_path,omitempty"`      // agent binary on the Telegraf host for exec_mode binary
+	Logging             LoggingConfig  `yaml:"logging,omitempty"`
+	PostRunWebhook      *WebhookConfig `yaml:"post_run_webhook,omitempty"`
+	AllowStatusScript   *bool          `yaml:"allow_status_scripts,omitempty"` // opt-in for monitor status_script
+	ManageDescription   *bool          `yaml:"manage_descriptions,omitempty"`  // false: leave descriptions to the UI
+	ManagedFields       []string       `yaml:"managed_fields,omitempty"`       // fields reconciled on existing monitors
+	StrictNotifications *bool          `yaml:"strict_notifications,omitempty"` // fail the run on unknown notification names
+	PushTokenBytes      int            `yaml:"push_token_bytes,omitempty"`     // random bytes per generated push token (default 16)
+	Outputs             []OutputConfig `yaml:"outputs,omitempty"`              // real Telegraf outputs, written as 01-outputs-*.conf
+}
+
+// OutputConfig is a Telegraf output plugin the generator writes next to the push
+// outputs, e.g. to send the collected metrics to InfluxDB as well.
+type OutputConfig struct {
+	Name     string         `yaml:"name,omitempty"`     // file name suffix (default: the plugin)
+	Plugin   string         `yaml:"plugin"`             // Telegraf output, e.g. influxdb_v2 or prometheus_client
+	Settings map[string]any `yaml:"settings,omitempty"` // plugin options as named by Telegraf
+}
+
+// FileName returns the name the output's config is generated under, before sanitizing.
+func (o *OutputConfig) FileName() string {
+	if o.Name != "" {
+		return o.Name
+	}
+	return o.Plugin
+}
+
+// TOML renders the output as a Telegraf [[outputs.<plugin>]] table.
+func (o *OutputConfig) TOML() (string, error) {
+	if !outputPluginPattern.MatchString(o.Plugin) {
+		return "", fmt.Errorf("output %s: plugin %q is not a Telegraf plugin name like influxdb_v2", o.FileName(), o.Plugin)
+	}
+	settings := o.Settings
+	if settings == nil {
+		settings = map[string]any{}
+	}
+	var buf bytes.Buffer
+	table := map[string]any{"outputs": map[string]any{o.Plugin: []map[string]any{settings}}}
+	if err := toml.NewEncoder(&buf).Encode(table); err != nil {
+		return "", fmt.Errorf("output %s: %w", o.FileName(), err)
+	}
+
+	// The encoder also opens the implicit [outputs] table and indents everything below it;
+	// drop that level so the config reads like a hand-written one
+	lines := strings.Split(strings.TrimSpace(strings.TrimPrefix(buf.String(), "[outputs]\n")), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, "  ")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+type NotificationConfig struct {
+	Name          string         `yaml:"name"`
+	Type          string         `yaml:"type"`                     // Uptime Kuma provider, e.g. telegram, slack, webhook
+	IsDefault     bool           `yaml:"is_default,omitempty"`     // enable for new monitors by default
+	ApplyExisting bool           `yaml:"apply_existing,omitempty"` // attach to all existing monitors on creation
+	Settings      map[string]any `yaml:"settings,omitempty"`       // provider fields as named by Uptime Kuma
+}
+
+// ProxyConfig is an Uptime Kuma proxy for http and keyword monitors. Uptime Kuma proxies
+// have no name, so they're matched by host and port; name is only for monitors to
+// reference it.
+type ProxyConfig struct {
+	Name     string `yaml:"name"`
+	Protocol string `yaml:"protocol,omitempty"` // http (default), https, socks, socks4, socks5, socks5h
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username,omitempty"` // enables proxy authentication
+	Password string `yaml:"password,omitempty"`
 }
 
+// ProxyProtocols are the proxy protocols Uptime Kuma supports.
+var ProxyProtocols = []string{"http", "https", "socks", "socks4", "socks5", "socks5h"}
+
+// FindProxy returns the proxies entry named name, or nil.
+func (c *Config) FindProxy(name string) *ProxyConfig {
+	for i := range c.Proxies {
+		if c.Proxies[i].Name == name {
+			return &c.Proxies[i]
+		}
+	}
+	return nil
+}
+
+// MaintenanceConfig is an Uptime Kuma maintenance window, matched by name. It either
+// recurs on a cron schedule or covers a single start/end window.
+type MaintenanceConfig struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description,omitempty"`
+	Monitors    []string `yaml:"monitors"`           // monitor or group names; a group covers its monitors
+	Cron        string   `yaml:"cron,omitempty"`     // start of each window, e.g. "0 2 * * 6"
+	Duration    int      `yaml:"duration,omitempty"` // cron: window length in minutes (default 60)
+	Start       string   `yaml:"start,omitempty"`    // single window, "2006-01-02 15:04" in timezone
+	End         string   `yaml:"end,omitempty"`
+	Timezone    string   `yaml:"timezone,omitempty"` // IANA name, UTC or SAME_AS_SERVER (default)
+}
+
+const (
+	// MaintenanceTimeLayout is the format of maintenance start and end times.
+	MaintenanceTimeLayout = "2006-01-02 15:04"
+	// DefaultMaintenanceDuration is the length in minutes of a cron window without duration.
+	DefaultMaintenanceDuration = 60
+	// MaintenanceServerTimezone makes Uptime Kuma use its own time zone for a window.
+	MaintenanceServerTimezone = "SAME_AS_SERVER"
+)
+
+// TimezoneOption returns the window's time zone as Uptime Kuma stores it.
+func (m *MaintenanceConfig) TimezoneOption() string {
+	if m.Timezone == "" {
+		return MaintenanceServerTimezone
+	}
+	return m.Timezone
+}
+
+// Location returns the time zone start and end are read in. Without a timezone (or with
+// SAME_AS_SERVER, whose zone the agent can't know) they are read as UTC.
+func (m *MaintenanceConfig) Location() (*time.Location, error) {
+	if m.Timezone == "" || m.Timezone == MaintenanceServerTimezone {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(m.Timezone)
+}
+
+// StatusPageConfig is an Uptime Kuma status page, matched by slug. Each group named in
+// monitors becomes a section listing the group's monitors; other monitor names are listed
+// together in one section.
+type StatusPageConfig struct {
+	Slug        string   `yaml:"slug"`
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description,omitempty"`
+	Theme       string   `yaml:"theme,omitempty"`     // light, dark or auto (default)
+	Published   *bool    `yaml:"published,omitempty"` // false hides the page (default true)
+	Monitors    []string `yaml:"monitors,omitempty"`  // monitor or group names (default: every configured group)
+	Section     string   `yaml:"section,omitempty"`   // section for monitors listed by name (default "Services")
+}
+
+// DefaultStatusPageSection is the section monitors listed by name are shown in.
+const DefaultStatusPageSection = "Services"
+
+// StatusPageThemes are the themes Uptime Kuma offers for status pages.
+var StatusPageThemes = []string{"light", "dark", "auto"}
+
 type GroupConfig struct {
 	Name              string   `yaml:"name"`
 	Description       *string  `yaml:"description,omitempty"`
 	NotificationNames []string `yaml:"notification_names,omitempty"`
+
+	// Monitors nested under a group belong to it without repeating group: on each entry.
+	// They are moved into the top-level sections when the config is loaded.
+	PushMonitors    []MonitorConfig `yaml:"push_monitors,omitempty"`
+	HTTPMonitors    []MonitorConfig `yaml:"http_monitors,omitempty"`
+	KeywordMonitors []MonitorConfig `yaml:"keyword_monitors,omitempty"`
+	TCPMonitors     []MonitorConfig `yaml:"tcp_monitors,omitempty"`
+	DNSMonitors     []MonitorConfig `yaml:"dns_monitors,omitempty"`
+	PingMonitors    []MonitorConfig `yaml:"ping_monitors,omitempty"`
+	MQTTMonitors    []MonitorConfig `yaml:"mqtt_monitors,omitempty"`
+	RawMonitors     []MonitorConfig `yaml:"raw_monitors,omitempty"`
 }
 
 type Config struct {
-	Version          string          `yaml:"version,omitempty"`
-	UptimeKumaURL    string          `yaml:"uptime_kuma_url"`
-	Username         string          `yaml:"username"`
-	Password         string          `yaml:"password"`
-	Groups           []GroupConfig   `yaml:"groups"`
-	Interval         int             `yaml:"interval"`
-	MaxRetries       int             `yaml:"max_retries"`
-	GlobalThresholds ThresholdConfig `yaml:"global_thresholds,omitempty"`
-	Agent            AgentConfig     `yaml:"agent,omitempty"`
-	PushMonitors     []MonitorConfig `yaml:"push_monitors,omitempty"`
-	HTTPMonitors     []MonitorConfig `yaml:"http_monitors,omitempty"`
-	// Deprecated: Use PushMonitors and HTTPMonitors instead
+	SchemaVersion    string               `yaml:"version,omitempty"` // see CurrentSchemaVersion
+	UptimeKumaURL    string               `yaml:"uptime_kuma_url"`
+	Username         string               `yaml:"username"`
+	Password         string               `yaml:"password"`
+	CACert           string               `yaml:"ca_cert,omitempty"` // PEM bundle trusted for HTTPS to Uptime Kuma
+	Groups           []GroupConfig        `yaml:"groups"`
+	Notifications    []NotificationConfig `yaml:"notifications,omitempty"`
+	Proxies          []ProxyConfig        `yaml:"proxies,omitempty"`
+	Maintenance      []MaintenanceConfig  `yaml:"maintenance,omitempty"`
+	StatusPage       *StatusPageConfig    `yaml:"status_page,omitempty"`
+	Interval         int                  `yaml:"interval"`
+	MaxRetries       int                  `yaml:"max_retries"`
+	GlobalThresholds ThresholdConfig      `yaml:"global_thresholds,omitempty"`
+	Agent            AgentConfig          `yaml:"agent,omitempty"`
+	PushMonitors     []MonitorConfig      `yaml:"push_monitors,omitempty"`
+	HTTPMonitors     []MonitorConfig      `yaml:"http_monitors,omitempty"`
+	KeywordMonitors  []MonitorConfig      `yaml:"keyword_monitors,omitempty"`
+	TCPMonitors      []MonitorConfig      `yaml:"tcp_monitors,omitempty"`
+	DNSMonitors      []MonitorConfig      `yaml:"dns_monitors,omitempty"`
+	PingMonitors     []MonitorConfig      `yaml:"ping_monitors,omitempty"`
+	MQTTMonitors     []MonitorConfig      `yaml:"mqtt_monitors,omitempty"`
+	RawMonitors      []MonitorConfig      `yaml:"raw_monitors,omitempty"`
+	// Deprecated: Use the typed monitor sections (push_monitors, http_monitors, ...) instead
 	Monitors []MonitorConfig `yaml:"monitors,omitempty"`
+
+	// SourceFiles lists the config files merged into this config, in merge order
+	SourceFiles []string `yaml:"-"`
+
+	// monitorSources records where each monitor (by name + group) was defined, as
+	// "<type> in <file>", for duplicate reporting
+	monitorSources map[string][]string
+
+	// inlineTokens names the push monitors with a push_token in the config files
+	inlineTokens []string
+
+	// migrations notes the changes made migrating the config files to CurrentSchemaVersion
+	migrations []string
 }
 
 type MonitorConfig struct {
-	Type              string   `yaml:"type"`
-	Name              string   `yaml:"name"`
-	Group             string   `yaml:"group,omitempty"`
-	Description       *string  `yaml:"description,omitempty"`
-	NotificationNames []string `yaml:"notification_names,omitempty"`
-	URL               string   `yaml:"url,omitempty"`
-	Threshold         float64  `yaml:"threshold,omitempty"` // ← Change to float64
-	Metric            string   `yaml:"metric,omitempty"`
-	Field             string   `yaml:"field,omitempty"`
-	Filesystem        string   `yaml:"filesystem,omitempty"`
-	ContainerName     string   `yaml:"container_name,omitempty"`
-	PushToken         string   `yaml:"push_token,omitempty"`
+	Type              string            `yaml:"type"`
+	Name              string            `yaml:"name"`
+	Group             string            `yaml:"group,omitempty"`
+	Description       *string           `yaml:"description,omitempty"`
+	NotificationNames []string          `yaml:"notification_names,omitempty"`
+	Active            *bool             `yaml:"active,omitempty"`             // false pauses the monitor without deleting it
+	URL               string            `yaml:"url,omitempty"`                // http, keyword; mqtt: broker URL (mqtt://, mqtts://, ws://, wss://)
+	Method            string            `yaml:"method,omitempty"`             // http, keyword: request method (default GET)
+	Headers           map[string]string `yaml:"headers,omitempty"`            // http, keyword: request headers
+	Proxy             string            `yaml:"proxy,omitempty"`              // http, keyword: name of a proxies entry
+	Body              string            `yaml:"body,omitempty"`               // http, keyword: request body (JSON is sent as json)
+	Timeout           int               `yaml:"timeout,omitempty"`            // http, keyword: request timeout in seconds (default 30)
+	MaxRedirects      *int              `yaml:"max_redirects,omitempty"`      // http, keyword: 0 disables redirects (default 10)
+	Keyword           string            `yaml:"keyword,omitempty"`            // keyword: text the response body must contain; mqtt: text the message must contain
+	InvertKeyword     bool              `yaml:"invert_keyword,omitempty"`     // keyword: fail when the text is present instead
+	Hostname          string            `yaml:"hostname,omitempty"`           // tcp, dns, ping
+	Port              int               `yaml:"port,omitempty"`               // tcp, dns (resolver port)
+	ResolverServer    string            `yaml:"resolver_server,omitempty"`    // dns
+	RecordType        string            `yaml:"record_type,omitempty"`        // dns: A, AAAA, CNAME, MX, ...
+	PacketSize        int               `yaml:"packet_size,omitempty"`        // ping (default 56 bytes)
+	Topic             string            `yaml:"topic,omitempty"`              // mqtt: topic to subscribe to
+	Username          string            `yaml:"username,omitempty"`           // mqtt: broker username
+	Password          string            `yaml:"password,omitempty"`           // mqtt: broker password
+	RawType           string            `yaml:"raw_type,omitempty"`           // raw: Uptime Kuma monitor type, e.g. steam or real-browser
+	Settings          map[string]any    `yaml:"settings,omitempty"`           // raw: monitor fields sent as-is, keyed by Uptime Kuma's JSON names
+	Threshold         *float64          `yaml:"threshold,omitempty"`          // push: unset for the global_thresholds default; 0 is a valid threshold
+	MinThreshold      *float64          `yaml:"min_threshold,omitempty"`      // push: down below this value (replaces threshold/operator)
+	MaxThreshold      *float64          `yaml:"max_threshold,omitempty"`      // push: down above this value (replaces threshold/operator)
+	RecoveryThreshold *float64          `yaml:"recovery_threshold,omitempty"` // push: once down, up again only past this value
+	PushEpsilon       *float64          `yaml:"push_epsilon,omitempty"`       // push: skip pushes keeping the status with a value change up to this
+	Unit              *string           `yaml:"unit,omitempty"`               // push: unit shown in the message ("" for none)
+	Aggregation       string            `yaml:"aggregation,omitempty"`        // push: last (default), avg, max, min, sum over matching series
+	Operator          string            `yaml:"operator,omitempty"`           // push: gt (default), gte, lt, lte, eq - value vs threshold means down
+	Metric            string            `yaml:"metric,omitempty"`
+	Field             string            `yaml:"field,omitempty"`
+	Fields            []string          `yaml:"fields,omitempty"`     // push: extra fields reported in the message next to field
+	PingField         string            `yaml:"ping_field,omitempty"` // push: field sent as the graphed ping value (default field)
+	Filesystem        string            `yaml:"filesystem,omitempty"`
+	Interface         string            `yaml:"interface,omitempty"`    // net: network interface (e.g. eth0)
+	PerCPU            bool              `yaml:"per_cpu,omitempty"`      // cpu: use per-core series instead of cpu-total
+	Sensor            string            `yaml:"sensor,omitempty"`       // temp: sensor tag (e.g. coretemp_package_id_0)
+	CustomInput       string            `yaml:"custom_input,omitempty"` // push: raw Telegraf input TOML producing metric
+	ContainerName     string            `yaml:"container_name,omitempty"`
+	InputInterval     string            `yaml:"collection_interval,omitempty"` // push: Telegraf interval for the metric's input, e.g. 10s
+	PushToken         string            `yaml:"push_token,omitempty"`
+	StatusScript      string            `yaml:"status_script,omitempty"`       // push: external script deciding status/message
+	ManageDescription *bool             `yaml:"manage_descriptions,omitempty"` // overrides agent.manage_descriptions
+	ManagedFields     []string          `yaml:"managed_fields,omitempty"`      // overrides agent.managed_fields
+	Tags              []TagConfig       `yaml:"tags,omitempty"`                // replaces the monitor's tags when set
+}
+
+// TagConfig is an Uptime Kuma tag attached to a monitor. Color belongs to the tag itself
+// and is only used when the agent has to create it.
+type TagConfig struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value,omitempty"`
+	Color string `yaml:"color,omitempty"` // e.g. #2563EB
 }
 
+// LoadMergedConfig loads config.yaml (or config.json) from dir and merges every
+// config.*.yaml and config.*.json over it in filename order, then applies the tokens file.
+// JSON files are read by the YAML parser rather than encoding/json: JSON is valid YAML, so
+// both formats share the yaml keys, environment expansion and defaulting without a second
+// set of struct tags to keep in sync. Tokens are saved to the tokens file in either case.
 func LoadMergedConfig(dir string) (*Config, error) {
 	// Load base config
-	baseFile := filepath.Join(dir, "config.yaml")
-	baseData, err := os.ReadFile(baseFile)
+	baseFile, err := baseConfigFile(dir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read base config: %w", err)
+		return nil, err
 	}
-
 	var baseConfig Config
-	if err := yaml.Unmarshal(baseData, &baseConfig); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal base config: %w", err)
+	baseData, err := os.ReadFile(baseFile)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// No base file: start empty and rely on the config.*.yaml overlays
+	case err != nil:
+		return nil, fmt.Errorf("failed to read base config: %w", err)
+	default:
+		if err := unmarshalConfig(baseData, &baseConfig); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal base config: %w", err)
+		}
+		if err := baseConfig.migrate(baseFile); err != nil {
+			return nil, err
+		}
+		baseConfig.inlineGroupMonitors()
+		baseConfig.SourceFiles = []string{baseFile}
+		baseConfig.recordMonitorSources(&baseConfig, baseFile)
 	}
 
 	// Find additional config files
-	additionalFiles, err := filepath.Glob(filepath.Join(dir, "config.*.yaml"))
-	if err != nil {
-		return nil, err
+	var additionalFiles []string
+	for _, pattern := range []string{"config.*.yaml", "config.*.json"} {
+		files, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		additionalFiles = append(additionalFiles, files...)
 	}
-	sort.Strings(additionalFiles) // Merge in consistent order
+	sort.Strings(additionalFiles) // Merge in consistent order, regardless of format
 
 	for _, file := range additionalFiles {
 		data, err := os.ReadFile(file)
@@ -98,17 +367,92 @@ func LoadMergedConfig(dir string) (*Config, error) {
 		}
 
 		var addConfig Config
-		if err := yaml.Unmarshal(data, &addConfig); err != nil {
+		if err := unmarshalConfig(data, &addConfig); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal %s: %w", file, err)
 		}
+		if err := addConfig.migrate(file); err != nil {
+			return nil, err
+		}
+		baseConfig.migrations = append(baseConfig.migrations, addConfig.migrations...)
+		addConfig.inlineGroupMonitors()
+		// The merge folds repeats within the file together, so check it on its own first
+		addConfig.recordMonitorSources(&addConfig, file)
+		if err := addConfig.checkDuplicateMonitors(); err != nil {
+			return nil, err
+		}
+		baseConfig.recordMonitorSources(&addConfig, file)
 
 		// Merge addConfig into baseConfig
 		baseConfig = mergeConfigs(baseConfig, addConfig)
+		baseConfig.SourceFiles = append(baseConfig.SourceFiles, file)
+	}
+	if len(baseConfig.SourceFiles) == 0 {
+		return nil, fmt.Errorf("no config files found in %s (expected config.yaml, config.json or config.*.yaml)", dir)
 	}
+	baseConfig.SchemaVersion = strconv.Itoa(CurrentSchemaVersion) // every file was migrated
+	if err := baseConfig.checkDuplicateMonitors(); err != nil {
+		return nil, err
+	}
+
+	tokens, err := loadTokens(filepath.Join(dir, TokensFile))
+	if err != nil {
+		return nil, err
+	}
+	baseConfig.applyTokens(tokens)
 
 	return &baseConfig, nil
 }
 
+// baseConfigFile returns the base config in dir: config.yaml, or config.json when there
+// is no YAML file. Having both is ambiguous and rejected.
+func baseConfigFile(dir string) (string, error) {
+	yamlFile := filepath.Join(dir, "config.yaml")
+	jsonFile := filepath.Join(dir, "config.json")
+	_, yamlErr := os.Stat(yamlFile)
+	_, jsonErr := os.Stat(jsonFile)
+
+	switch {
+	case yamlErr == nil && jsonErr == nil:
+		return "", fmt.Errorf("both %s and %s exist; keep only one base config", yamlFile, jsonFile)
+	case jsonErr == nil:
+		return jsonFile, nil
+	}
+	return yamlFile, nil // may not exist; overlays alone are enough
+}
+
+// mergeLogging overlays the logging fields set in add onto base.
+func mergeLogging(base, add LoggingConfig) LoggingConfig {
+	for _, f := range []struct{ dst, src *string }{
+		{&base.Level, &add.Level},
+		{&base.Format, &add.Format},
+		{&base.InternalLogDirectory, &add.InternalLogDirectory},
+		{&base.HostLogDirectory, &add.HostLogDirectory},
+		{&base.SocketIOLogLevel, &add.SocketIOLogLevel},
+		{&base.Output, &add.Output},
+		{&base.SyslogNetwork, &add.SyslogNetwork},
+		{&base.SyslogAddress, &add.SyslogAddress},
+		{&base.SyslogFacility, &add.SyslogFacility},
+		{&base.SyslogTag, &add.SyslogTag},
+	} {
+		if *f.src != "" {
+			*f.dst = *f.src
+		}
+	}
+	if add.MaxSize > 0 {
+		base.MaxSize = add.MaxSize
+	}
+	if add.MaxAge > 0 {
+		base.MaxAge = add.MaxAge
+	}
+	if add.MaxBackups > 0 {
+		base.MaxBackups = add.MaxBackups
+	}
+	if add.Compress != nil {
+		base.Compress = add.Compress
+	}
+	return base
+}
+
 func mergeConfigs(base, add Config) Config {
 	// Merge simple fields (last wins)
 	if add.UptimeKumaURL != "" {
@@ -120,6 +464,9 @@ func mergeConfigs(base, add Config) Config {
 	if add.Password != "" {
 		base.Password = add.Password
 	}
+	if add.CACert != "" {
+		base.CACert = add.CACert
+	}
 	if add.Interval > 0 {
 		base.Interval = add.Interval
 	}
@@ -134,19 +481,54 @@ func mergeConfigs(base, add Config) Config {
 	if add.Agent.DockerImage != "" {
 		base.Agent.DockerImage = add.Agent.DockerImage
 	}
+	if add.Agent.PinImageDigest != nil {
+		base.Agent.PinImageDigest = add.Agent.PinImageDigest
+	}
+	if add.Agent.ExecMode != "" {
+		base.Agent.ExecMode = add.Agent.ExecMode
+	}
+	if add.Agent.BinaryPath != "" {
+		base.Agent.BinaryPath = add.Agent.BinaryPath
+	}
+	if add.Agent.PostRunWebhook != nil {
+		base.Agent.PostRunWebhook = add.Agent.PostRunWebhook
+	}
+	if add.Agent.AllowStatusScript != nil {
+		base.Agent.AllowStatusScript = add.Agent.AllowStatusScript
+	}
+	if add.Agent.ManageDescription != nil {
+		base.Agent.ManageDescription = add.Agent.ManageDescription
+	}
+	if add.Agent.ManagedFields != nil {
+		base.Agent.ManagedFields = add.Agent.ManagedFields
+	}
+	if add.Agent.Outputs != nil {
+		base.Agent.Outputs = add.Agent.Outputs
+	}
+	if add.Agent.StrictNotifications != nil {
+		base.Agent.StrictNotifications = add.Agent.StrictNotifications
+	}
+	if add.Agent.PushTokenBytes > 0 {
+		base.Agent.PushTokenBytes = add.Agent.PushTokenBytes
+	}
+	base.Agent.Logging = mergeLogging(base.Agent.Logging, add.Agent.Logging)
 
 	// Merge GlobalThresholds (last config wins)
-	if add.GlobalThresholds.CPU > 0 {
+	if add.GlobalThresholds.CPU != nil {
 		base.GlobalThresholds.CPU = add.GlobalThresholds.CPU
 	}
-	if add.GlobalThresholds.RAM > 0 {
+	if add.GlobalThresholds.RAM != nil {
 		base.GlobalThresholds.RAM = add.GlobalThresholds.RAM
 	}
-	if add.GlobalThresholds.Disk > 0 {
+	if add.GlobalThresholds.Disk != nil {
 		base.GlobalThresholds.Disk = add.GlobalThresholds.Disk
 	}
+	if add.GlobalThresholds.Default != nil {
+		base.GlobalThresholds.Default = add.GlobalThresholds.Default
+	}
 
-	// Merge Groups (avoid duplicates by name)
+	// Merge Groups (avoid duplicates by name). Nested monitors were already moved to the
+	// typed sections, so a group repeated in another file still contributes its monitors.
 	groupNameMap := make(map[string]bool)
 	for _, g := range base.Groups {
 		groupNameMap[g.Name] = true
@@ -158,96 +540,185 @@ func mergeConfigs(base, add Config) Config {
 		}
 	}
 
-	// Merge PushMonitors (avoid duplicates by name + group)
-	pushMonitorMap := make(map[string]bool)
-	for _, m := range base.PushMonitors {
-		key := m.Name + "|" + m.Group
-		pushMonitorMap[key] = true
+	// Merge Notifications (avoid duplicates by name)
+	notificationNameMap := make(map[string]bool)
+	for _, n := range base.Notifications {
+		notificationNameMap[n.Name] = true
 	}
-	for _, m := range add.PushMonitors {
-		key := m.Name + "|" + m.Group
-		if !pushMonitorMap[key] {
-			base.PushMonitors = append(base.PushMonitors, m)
-			pushMonitorMap[key] = true
+	for _, n := range add.Notifications {
+		if !notificationNameMap[n.Name] {
+			base.Notifications = append(base.Notifications, n)
+			notificationNameMap[n.Name] = true
 		}
 	}
 
-	// Merge HTTPMonitors (avoid duplicates by name + group)
-	httpMonitorMap := make(map[string]bool)
-	for _, m := range base.HTTPMonitors {
-		key := m.Name + "|" + m.Group
-		httpMonitorMap[key] = true
+	// Merge StatusPage (last config wins)
+	if add.StatusPage != nil {
+		base.StatusPage = add.StatusPage
 	}
-	for _, m := range add.HTTPMonitors {
-		key := m.Name + "|" + m.Group
-		if !httpMonitorMap[key] {
-			base.HTTPMonitors = append(base.HTTPMonitors, m)
-			httpMonitorMap[key] = true
+
+	// Merge Proxies (avoid duplicates by name)
+	proxyNameMap := make(map[string]bool)
+	for _, p := range base.Proxies {
+		proxyNameMap[p.Name] = true
+	}
+	for _, p := range add.Proxies {
+		if !proxyNameMap[p.Name] {
+			base.Proxies = append(base.Proxies, p)
+			proxyNameMap[p.Name] = true
 		}
 	}
 
-	// Merge legacy Monitors (avoid duplicates by name)
-	monitorMap := make(map[string]bool)
-	for _, m := range base.Monitors {
-		monitorMap[m.Name] = true
+	// Merge Maintenance (avoid duplicates by name)
+	maintenanceNameMap := make(map[string]bool)
+	for _, m := range base.Maintenance {
+		maintenanceNameMap[m.Name] = true
 	}
-	for _, m := range add.Monitors {
-		if !monitorMap[m.Name] {
-			base.Monitors = append(base.Monitors, m)
-			monitorMap[m.Name] = true
+	for _, m := range add.Maintenance {
+		if !maintenanceNameMap[m.Name] {
+			base.Maintenance = append(base.Maintenance, m)
+			maintenanceNameMap[m.Name] = true
 		}
 	}
 
+	// Merge typed monitor sections (same name + group overlays the earlier definition)
+	baseSections, addSections := base.typedSections(), add.typedSections()
+	for i := range baseSections {
+		*baseSections[i].Monitors = mergeMonitorList(*baseSections[i].Monitors, *addSections[i].Monitors, monitorKey)
+	}
+
+	// Merge legacy Monitors (same name overlays the earlier definition)
+	base.Monitors = mergeMonitorList(base.Monitors, add.Monitors, legacyMonitorKey)
+
 	return base
 }
 
-func SaveConfig(configPath string, cfg *Config) error {
-	// Deduplicate monitors before saving to prevent accumulation of duplicates
-	cfg.deduplicateMonitors()
+// monitorSection pairs a typed monitor list with the monitor type its entries get.
+type monitorSection struct {
+	Type     string
+	Monitors *[]MonitorConfig
+}
+
+// typedSections lists the typed monitor sections in a fixed order, which is also the
+// order GetAllMonitors returns them in.
+func (c *Config) typedSections() []monitorSection {
+	return []monitorSection{
+		{Type: "push", Monitors: &c.PushMonitors},
+		{Type: "http", Monitors: &c.HTTPMonitors},
+		{Type: "keyword", Monitors: &c.KeywordMonitors},
+		{Type: "tcp", Monitors: &c.TCPMonitors},
+		{Type: "dns", Monitors: &c.DNSMonitors},
+		{Type: "ping", Monitors: &c.PingMonitors},
+		{Type: "mqtt", Monitors: &c.MQTTMonitors},
+		{Type: "raw", Monitors: &c.RawMonitors},
+	}
+}
 
-	data, err := yaml.Marshal(cfg)
-	if err != nil {
-		return err
+// typedSections lists the monitor sections nested under the group.
+func (g *GroupConfig) typedSections() []monitorSection {
+	return []monitorSection{
+		{Type: "push", Monitors: &g.PushMonitors},
+		{Type: "http", Monitors: &g.HTTPMonitors},
+		{Type: "keyword", Monitors: &g.KeywordMonitors},
+		{Type: "tcp", Monitors: &g.TCPMonitors},
+		{Type: "dns", Monitors: &g.DNSMonitors},
+		{Type: "ping", Monitors: &g.PingMonitors},
+		{Type: "mqtt", Monitors: &g.MQTTMonitors},
+		{Type: "raw", Monitors: &g.RawMonitors},
 	}
-	return os.WriteFile(configPath, data, 0o644)
 }
 
-// deduplicateMonitors removes duplicate monitors from the config
-func (c *Config) deduplicateMonitors() {
-	// Deduplicate PushMonitors
-	pushMonitorMap := make(map[string]bool)
-	var deduplicatedPush []MonitorConfig
-	for _, m := range c.PushMonitors {
-		key := m.Name + "|" + m.Group
-		if !pushMonitorMap[key] {
-			deduplicatedPush = append(deduplicatedPush, m)
-			pushMonitorMap[key] = true
+// inlineGroupMonitors moves monitors nested under groups into the matching top-level
+// section with their group set, so the rest of the agent only deals with one layout.
+func (c *Config) inlineGroupMonitors() {
+	sections := c.typedSections()
+	for i := range c.Groups {
+		g := &c.Groups[i]
+		for j, nested := range g.typedSections() {
+			for _, m := range *nested.Monitors {
+				m.Group = g.Name
+				*sections[j].Monitors = append(*sections[j].Monitors, m)
+			}
+			*nested.Monitors = nil
 		}
 	}
-	c.PushMonitors = deduplicatedPush
+}
 
-	// Deduplicate HTTPMonitors
-	httpMonitorMap := make(map[string]bool)
-	var deduplicatedHTTP []MonitorConfig
-	for _, m := range c.HTTPMonitors {
-		key := m.Name + "|" + m.Group
-		if !httpMonitorMap[key] {
-			deduplicatedHTTP = append(deduplicatedHTTP, m)
-			httpMonitorMap[key] = true
+// mergeMonitorList merges monitors from add into base. A monitor whose key is already
+// present overlays its set fields onto the existing entry; new keys are appended.
+func mergeMonitorList(base, add []MonitorConfig, key func(MonitorConfig) string) []MonitorConfig {
+	index := make(map[string]int)
+	for i, m := range base {
+		index[key(m)] = i
+	}
+	for _, m := range add {
+		k := key(m)
+		if i, ok := index[k]; ok {
+			overlayMonitor(&base[i], m)
+			continue
 		}
+		base = append(base, m)
+		index[k] = len(base) - 1
+	}
+	return base
+}
+
+// overlayMonitor copies every non-zero field of add onto base, so an overlay only needs
+// the fields it changes. Plain booleans can be switched on but not back off.
+func overlayMonitor(base *MonitorConfig, add MonitorConfig) {
+	dst, src := reflect.ValueOf(base).Elem(), reflect.ValueOf(add)
+	for i := 0; i < src.NumField(); i++ {
+		if f := src.Field(i); !f.IsZero() {
+			dst.Field(i).Set(f)
+		}
+	}
+}
+
+// recordMonitorSources notes file as the origin of every monitor defined in fileCfg.
+func (c *Config) recordMonitorSources(fileCfg *Config, file string) {
+	if c.monitorSources == nil {
+		c.monitorSources = make(map[string][]string)
 	}
-	c.HTTPMonitors = deduplicatedHTTP
+	for _, m := range fileCfg.GetAllMonitors() {
+		key := monitorKey(m)
+		c.monitorSources[key] = append(c.monitorSources[key], fmt.Sprintf("%s in %s", m.Type, filepath.Base(file)))
+	}
+}
 
-	// Deduplicate legacy Monitors
-	monitorMap := make(map[string]bool)
-	var deduplicatedLegacy []MonitorConfig
-	for _, m := range c.Monitors {
-		if !monitorMap[m.Name] {
-			deduplicatedLegacy = append(deduplicatedLegacy, m)
-			monitorMap[m.Name] = true
+// checkDuplicateMonitors rejects monitors that share a name within a group, e.g. a push
+// and an http monitor both called "api". It runs on each overlay file before merging and
+// on the merged config; repeating a monitor in a later file of the same section is an
+// overlay, not a duplicate.
+func (c *Config) checkDuplicateMonitors() error {
+	count := make(map[string]int)
+	var keys []string
+	for _, m := range c.GetAllMonitors() {
+		key := monitorKey(m)
+		if count[key] == 1 {
+			keys = append(keys, key)
 		}
+		count[key]++
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	duplicates := make([]string, 0, len(keys))
+	for _, key := range keys {
+		name, group, _ := strings.Cut(key, "|")
+		duplicates = append(duplicates, fmt.Sprintf("%q in group %q (%s)", name, group, strings.Join(c.monitorSources[key], ", ")))
 	}
-	c.Monitors = deduplicatedLegacy
+	return fmt.Errorf("duplicate monitor names: %s", strings.Join(duplicates, "; "))
+}
+
+// monitorKey identifies a typed-section monitor; names only need to be unique per group.
+func monitorKey(m MonitorConfig) string {
+	return m.Name + "|" + m.Group
+}
+
+// legacyMonitorKey identifies a legacy monitors entry, which is unique by name alone.
+func legacyMonitorKey(m MonitorConfig) string {
+	return m.Name
 }
 
 func (m *MonitorConfig) ResolveMetrics(cfg *Config) {
@@ -258,8 +729,31 @@ func (m *MonitorConfig) ResolveMetrics(cfg *Config) {
 		if strings.Contains(lowerName, "cpu") {
 			m.Metric = "cpu"
 			if m.Field == "" {
+				// Read from the aggregated cpu=cpu-total series unless per_cpu is set
 				m.Field = "usage_user" // or "usage_system + usage_user"
 			}
+		} else if strings.Contains(lowerName, "temp") {
+			m.Metric = "temp"
+			if m.Field == "" {
+				m.Field = "temp"
+			}
+		} else if strings.Contains(lowerName, "load") {
+			m.Metric = "system"
+			if m.Field == "" {
+				switch {
+				case strings.Contains(lowerName, "15"):
+					m.Field = "load15"
+				case strings.Contains(lowerName, "5"):
+					m.Field = "load5"
+				default:
+					m.Field = "load1"
+				}
+			}
+		} else if strings.Contains(lowerName, "swap") { // before "mem": "Swap Memory" is swap
+			m.Metric = "swap"
+			if m.Field == "" {
+				m.Field = "used_percent"
+			}
 		} else if strings.Contains(lowerName, "ram") || strings.Contains(lowerName, "mem") {
 			m.Metric = "mem"
 			if m.Field == "" {
@@ -274,54 +768,223 @@ func (m *MonitorConfig) ResolveMetrics(cfg *Config) {
 			if m.Filesystem == "" {
 				if strings.Contains(lowerName, "root") {
 					m.Filesystem = "/"
-				} else if strings.Contains(lowerName, "data") {
-					m.Filesystem = "/mnt/data/uptime-kuma-test"
 				}
 			}
 		}
 	}
 
-	// Default threshold from global_thresholds or fallback to 90
-	if m.Threshold == 0 {
+	// Default an unset threshold from global_thresholds, then global_thresholds.default, then built-in
+	if m.Threshold == nil {
+		var global *float64
+		fallback := float64(DefaultThreshold)
 		switch m.Metric {
 		case "cpu", "docker_container_cpu":
-			if cfg.GlobalThresholds.CPU > 0 {
-				m.Threshold = cfg.GlobalThresholds.CPU
-			} else {
-				m.Threshold = 90
-			}
+			global = cfg.GlobalThresholds.CPU
 		case "mem":
-			if cfg.GlobalThresholds.RAM > 0 {
-				m.Threshold = cfg.GlobalThresholds.RAM
-			} else {
-				m.Threshold = 90
-			}
+			global = cfg.GlobalThresholds.RAM
 		case "disk":
-			if cfg.GlobalThresholds.Disk > 0 {
-				m.Threshold = cfg.GlobalThresholds.Disk
-			} else {
-				m.Threshold = 85
-			}
-		default:
-			m.Threshold = 90
+			global, fallback = cfg.GlobalThresholds.Disk, DefaultDiskThreshold
+		}
+		threshold := fallback
+		switch {
+		case global != nil:
+			threshold = *global
+		case cfg.GlobalThresholds.Default != nil:
+			threshold = *cfg.GlobalThresholds.Default
 		}
+		m.Threshold = &threshold
 	}
 }
 
-// GetAllMonitors returns a consolidated list of all monitors (for backward compatibility)
-func (c *Config) GetAllMonitors() []MonitorConfig {
-	var all []MonitorConfig
+// ValueUnit returns the unit shown after the monitor's values in push messages. An
+// explicit unit wins; otherwise "%" for percentage fields and nothing for absolute
+// values such as load averages or counters.
+func (m *MonitorConfig) ValueUnit() string {
+	if m.Unit != nil {
+		return *m.Unit
+	}
+	return m.FieldUnit(m.Field)
+}
+
+// TagFilters returns the key=value tags that pick the monitor's series out of a
+// multi-series metric, e.g. path=/ for a disk monitor or cpu=cpu-total for cpu.
+func (m *MonitorConfig) TagFilters() []string {
+	var tags []string
+	switch {
+	case m.Metric == "disk" && m.Filesystem != "":
+		tags = append(tags, "path="+strings.TrimSpace(m.Filesystem))
+	case m.Metric == "net" && m.Interface != "":
+		tags = append(tags, "interface="+strings.TrimSpace(m.Interface))
+	case m.Metric == "temp" && m.Sensor != "":
+		tags = append(tags, "sensor="+m.Sensor)
+	case m.Metric == "cpu" && !m.PerCPU:
+		tags = append(tags, "cpu=cpu-total")
+	case strings.HasPrefix(m.Metric, "docker_container_") && m.ContainerName != "":
+		tags = append(tags, "container_name="+m.ContainerName)
+	}
+	return tags
+}
+
+// HasRange reports whether the monitor checks its value against min_threshold and/or
+// max_threshold instead of threshold and operator.
+func (m *MonitorConfig) HasRange() bool {
+	return m.MinThreshold != nil || m.MaxThreshold != nil
+}
 
-	// Add push monitors with type set
-	for _, m := range c.PushMonitors {
-		m.Type = "push"
-		all = append(all, m)
+// IsActive reports whether the monitor should be running; monitors are active unless
+// active: false is set.
+func (m *MonitorConfig) IsActive() bool {
+	return m.Active == nil || *m.Active
+}
+
+// FieldUnit returns the unit for one of the monitor's fields, ignoring an explicit unit.
+func (m *MonitorConfig) FieldUnit(field string) string {
+	if m.Metric == "temp" {
+		return "°C"
+	}
+	if isPercentField(m.Metric, field) {
+		return "%"
 	}
+	return ""
+}
 
-	// Add HTTP monitors with type set
-	for _, m := range c.HTTPMonitors {
-		m.Type = "http"
-		all = append(all, m)
+// PushFields returns field followed by the extra fields and ping_field, without
+// duplicates. Only the first is compared to the threshold.
+func (m *MonitorConfig) PushFields() []string {
+	var fields []string
+	for _, f := range append(append([]string{m.Field}, m.Fields...), m.PingField) {
+		if f != "" && !slices.Contains(fields, f) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Monitor attributes the agent can reconcile on existing monitors (see managed_fields)
+const (
+	ManagedFieldDescription   = "description"
+	ManagedFieldNotifications = "notifications"
+	ManagedFieldInterval      = "interval"
+	ManagedFieldMaxRetries    = "max_retries"
+	ManagedFieldSettings      = "settings" // type-specific settings: url, hostname, port, ...
+	ManagedFieldTags          = "tags"     // only for monitors with a tags list
+)
+
+// KnownManagedFields lists every valid managed_fields entry.
+var KnownManagedFields = []string{
+	ManagedFieldDescription,
+	ManagedFieldNotifications,
+	ManagedFieldInterval,
+	ManagedFieldMaxRetries,
+	ManagedFieldSettings,
+	ManagedFieldTags,
+}
+
+// DefaultManagedFields is used when no managed_fields list is configured.
+var DefaultManagedFields = []string{
+	ManagedFieldDescription,
+	ManagedFieldNotifications,
+	ManagedFieldSettings,
+	ManagedFieldTags,
+}
+
+// Manages reports whether the agent reconciles field on existing monitor m (or on groups
+// when m is nil). A per-monitor managed_fields list replaces agent.managed_fields, which
+// replaces DefaultManagedFields; manage_descriptions: false always excludes descriptions.
+func (c *Config) Manages(m *MonitorConfig, field string) bool {
+	if field == ManagedFieldDescription && !c.ManagesDescription(m) {
+		return false
+	}
+
+	fields := DefaultManagedFields
+	if c.Agent.ManagedFields != nil {
+		fields = c.Agent.ManagedFields
+	}
+	if m != nil && m.ManagedFields != nil {
+		fields = m.ManagedFields
+	}
+
+	for _, f := range fields {
+		if strings.EqualFold(f, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// StrictNotifications reports whether unknown notification names fail the run instead
+// of only logging a warning.
+func (c *Config) StrictNotifications() bool {
+	return c.Agent.StrictNotifications != nil && *c.Agent.StrictNotifications
+}
+
+// Values of agent.exec_mode, how the generated Telegraf exec runs push-metric
+const (
+	ExecModeDocker = "docker" // in a short-lived container of agent.docker_image
+	ExecModeBinary = "binary" // the agent binary installed on the Telegraf host
+)
+
+// ExecModes lists every valid agent.exec_mode.
+var ExecModes = []string{ExecModeDocker, ExecModeBinary}
+
+// DefaultBinaryPath is where exec_mode binary expects the agent without binary_path.
+const DefaultBinaryPath = "/usr/local/bin/uptime-kuma-agent"
+
+// ExecMode returns agent.exec_mode, docker by default.
+func (c *Config) ExecMode() string {
+	if c.Agent.ExecMode != "" {
+		return strings.ToLower(c.Agent.ExecMode)
+	}
+	return ExecModeDocker
+}
+
+// BinaryPath returns the agent binary the exec runs in exec_mode binary.
+func (c *Config) BinaryPath() string {
+	if c.Agent.BinaryPath != "" {
+		return c.Agent.BinaryPath
+	}
+	return DefaultBinaryPath
+}
+
+// DefaultPushTokenBytes is the size of generated push tokens, and the minimum
+// agent.push_token_bytes accepts: 16 random bytes, 32 hex characters.
+const DefaultPushTokenBytes = 16
+
+// PushTokenBytes returns the number of random bytes in a generated push token.
+func (c *Config) PushTokenBytes() int {
+	if c.Agent.PushTokenBytes > 0 {
+		return c.Agent.PushTokenBytes
+	}
+	return DefaultPushTokenBytes
+}
+
+// ManagesDescription reports whether the agent reconciles descriptions for m (or for
+// groups when m is nil). The per-monitor setting wins over agent.manage_descriptions,
+// and descriptions are managed by default.
+func (c *Config) ManagesDescription(m *MonitorConfig) bool {
+	if m != nil && m.ManageDescription != nil {
+		return *m.ManageDescription
+	}
+	if c.Agent.ManageDescription != nil {
+		return *c.Agent.ManageDescription
+	}
+	return true
+}
+
+// GetAllMonitors returns every configured monitor as one flat list: the typed sections in
+// typedSections order (each entry's Type set from its section), then the legacy monitors
+// list. Monitors nested under groups are included, since loading moves them into the
+// typed sections with Group set; groups themselves are not returned. The entries are
+// copies, so changes to them don't reach the config.
+func (c *Config) GetAllMonitors() []MonitorConfig {
+	var all []MonitorConfig
+
+	// Add typed monitors with type set
+	for _, section := range c.typedSections() {
+		for _, m := range *section.Monitors {
+			m.Type = section.Type
+			all = append(all, m)
+		}
 	}
 
 	// Include deprecated Monitors for backward compatibility (they already have type set)
@@ -329,16 +992,45 @@ func (c *Config) GetAllMonitors() []MonitorConfig {
 	return all
 }
 
+// Summary describes what was loaded, e.g.
+// "Loaded 6 monitors (http=2, push=4) across 2 config files, group=web"
+func (c *Config) Summary() string {
+	all := c.GetAllMonitors()
+
+	countByType := make(map[string]int)
+	for _, m := range all {
+		countByType[m.Type]++
+	}
+	types := make([]string, 0, len(countByType))
+	for t := range countByType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	counts := make([]string, 0, len(types))
+	for _, t := range types {
+		counts = append(counts, fmt.Sprintf("%s=%d", t, countByType[t]))
+	}
+
+	groupNames := make([]string, 0, len(c.Groups))
+	for _, g := range c.Groups {
+		groupNames = append(groupNames, g.Name)
+	}
+
+	return fmt.Sprintf("Loaded %d monitors (%s) across %d config files, group=%s",
+		len(all), strings.Join(counts, ", "), len(c.SourceFiles), strings.Join(groupNames, ","))
+}
+
 // ResolveAllMetrics sets smart defaults and thresholds for all monitors using global config
 func (c *Config) ResolveAllMetrics() {
 	allMonitors := c.GetAllMonitors()
 	for i := range allMonitors {
 		allMonitors[i].ResolveMetrics(c)
 	}
-	// Update the slices with resolved metrics
-	copy(c.PushMonitors, allMonitors[:len(c.PushMonitors)])
-	copy(c.HTTPMonitors, allMonitors[len(c.PushMonitors):len(c.PushMonitors)+len(c.HTTPMonitors)])
-	if len(c.Monitors) > 0 {
-		copy(c.Monitors, allMonitors[len(c.PushMonitors)+len(c.HTTPMonitors):])
+	// Update the slices with resolved metrics (same order as GetAllMonitors)
+	offset := 0
+	for _, section := range c.typedSections() {
+		copy(*section.Monitors, allMonitors[offset:offset+len(*section.Monitors)])
+		offset += len(*section.Monitors)
 	}
+	copy(c.Monitors, allMonitors[offset:])
 }
@@ -0,0 +1,387 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const yamlConfig = `version: "2"
+uptime_kuma_url: ${TEST_KUMA_URL}
+interval: 30
+groups:
+  - name: Servers
+    push_monitors:
+      - name: Disk
+        metric: disk
+        filesystem: /data
+        threshold: 0
+        tags:
+          - name: env
+            value: prod
+http_monitors:
+  - name: API
+    url: https://api.example.com/health
+`
+
+const jsonConfig = `{
+  "version": "2",
+  "uptime_kuma_url": "${TEST_KUMA_URL}",
+  "interval": 30,
+  "groups": [
+    {
+      "name": "Servers",
+      "push_monitors": [
+        {
+          "name": "Disk",
+          "metric": "disk",
+          "filesystem": "/data",
+          "threshold": 0,
+          "tags": [{"name": "env", "value": "prod"}]
+        }
+      ]
+    }
+  ],
+  "http_monitors": [
+    {"name": "API", "url": "https://api.example.com/health"}
+  ]
+}
+`
+
+func TestLoadJSONConfigMatchesYAML(t *testing.T) {
+	t.Setenv("TEST_KUMA_URL", "http://kuma:3001")
+
+	fromYAML, err := LoadMergedConfig(writeConfig(t, map[string]string{"config.yaml": yamlConfig}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := LoadMergedConfig(writeConfig(t, map[string]string{"config.json": jsonConfig}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fromJSON.UptimeKumaURL != "http://kuma:3001" {
+		t.Errorf("uptime_kuma_url = %q, want the expanded environment value", fromJSON.UptimeKumaURL)
+	}
+	if fromJSON.Interval != fromYAML.Interval {
+		t.Errorf("interval = %d from JSON, %d from YAML", fromJSON.Interval, fromYAML.Interval)
+	}
+	if got, want := fromJSON.GetAllMonitors(), fromYAML.GetAllMonitors(); !reflect.DeepEqual(got, want) {
+		t.Errorf("monitors from JSON:\n%+v\nwant (from YAML):\n%+v", got, want)
+	}
+	if m := fromJSON.FindPushMonitor("Disk", "Servers"); m == nil || m.Threshold == nil || *m.Threshold != 0 {
+		t.Errorf("Disk threshold from JSON = %v, want an explicit 0", m)
+	}
+}
+
+func TestLoadMergesJSONAndYAMLByFilename(t *testing.T) {
+	t.Setenv("TEST_KUMA_URL", "http://kuma:3001")
+	dir := writeConfig(t, map[string]string{
+		"config.json":       jsonConfig,
+		"config.10-a.yaml":  "interval: 60\n",
+		"config.20-b.json":  `{"interval": 90, "http_monitors": [{"name": "API", "url": "https://api.example.com/ready"}]}`,
+		"config.30-c.yaml":  "http_monitors:\n  - name: Web\n    url: https://www.example.com\n",
+		"config.notes.conf": "not a config file",
+	})
+
+	cfg, err := LoadMergedConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	for _, f := range cfg.SourceFiles {
+		files = append(files, filepath.Base(f))
+	}
+	wantFiles := []string{"config.json", "config.10-a.yaml", "config.20-b.json", "config.30-c.yaml"}
+	if !reflect.DeepEqual(files, wantFiles) {
+		t.Errorf("source files = %v, want %v", files, wantFiles)
+	}
+	if cfg.Interval != 90 {
+		t.Errorf("interval = %d, want 90 from the last file setting it", cfg.Interval)
+	}
+	var urls []string
+	for _, m := range cfg.HTTPMonitors {
+		urls = append(urls, m.Name+"="+m.URL)
+	}
+	wantURLs := []string{"API=https://api.example.com/ready", "Web=https://www.example.com"}
+	if !reflect.DeepEqual(urls, wantURLs) {
+		t.Errorf("http monitors = %v, want %v", urls, wantURLs)
+	}
+}
+
+func TestJSONConfigTokensRoundTrip(t *testing.T) {
+	t.Setenv("TEST_KUMA_URL", "http://kuma:3001")
+	dir := writeConfig(t, map[string]string{"config.json": jsonConfig})
+
+	cfg, err := LoadMergedConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.FindPushMonitor("Disk", "Servers").PushToken = "json-token"
+	if _, err := SaveTokens(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != jsonConfig {
+		t.Errorf("config.json changed by saving tokens:\n%s", data)
+	}
+	reloaded, err := LoadMergedConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.FindPushMonitor("Disk", "Servers").PushToken; got != "json-token" {
+		t.Errorf("reloaded push token = %q, want json-token", got)
+	}
+}
+
+func TestGetAllMonitorsReturnsEveryTypedSection(t *testing.T) {
+	cfg := &Config{
+		PushMonitors:    []MonitorConfig{{Name: "push"}},
+		HTTPMonitors:    []MonitorConfig{{Name: "http"}},
+		KeywordMonitors: []MonitorConfig{{Name: "keyword"}},
+		TCPMonitors:     []MonitorConfig{{Name: "tcp"}},
+		DNSMonitors:     []MonitorConfig{{Name: "dns"}},
+		PingMonitors:    []MonitorConfig{{Name: "ping"}},
+		MQTTMonitors:    []MonitorConfig{{Name: "mqtt"}},
+		RawMonitors:     []MonitorConfig{{Name: "raw"}},
+		Monitors:        []MonitorConfig{{Name: "legacy", Type: "port"}},
+	}
+
+	var got []string
+	for _, m := range cfg.GetAllMonitors() {
+		got = append(got, m.Name+":"+m.Type)
+	}
+	want := []string{"push:push", "http:http", "keyword:keyword", "tcp:tcp", "dns:dns", "ping:ping", "mqtt:mqtt", "raw:raw", "legacy:port"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAllMonitors = %v, want %v", got, want)
+	}
+
+	all := cfg.GetAllMonitors()
+	all[0].Name = "changed"
+	if cfg.PushMonitors[0].Name != "push" || cfg.PushMonitors[0].Type != "" {
+		t.Errorf("GetAllMonitors changed the config: %+v", cfg.PushMonitors[0])
+	}
+}
+
+func TestGetAllMonitorsIncludesGroupAndLegacyMonitors(t *testing.T) {
+	dir := writeConfig(t, map[string]string{"config.yaml": `version: "2"
+uptime_kuma_url: http://kuma:3001
+groups:
+  - name: Servers
+    push_monitors:
+      - name: CPU
+        metric: cpu
+    http_monitors:
+      - name: Web
+        url: https://www.example.com
+  - name: Network
+    ping_monitors:
+      - name: Gateway
+        hostname: 192.168.1.1
+tcp_monitors:
+  - name: SSH
+    hostname: host
+    port: 22
+monitors:
+  - name: Old
+    type: http
+    url: https://old.example.com
+`})
+
+	cfg, err := LoadMergedConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, m := range cfg.GetAllMonitors() {
+		got = append(got, m.Type+":"+m.Name+"@"+m.Group)
+	}
+	want := []string{"push:CPU@Servers", "http:Web@Servers", "tcp:SSH@", "ping:Gateway@Network", "http:Old@"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAllMonitors = %v, want %v", got, want)
+	}
+	for _, g := range cfg.Groups {
+		if len(g.PushMonitors)+len(g.HTTPMonitors)+len(g.PingMonitors) != 0 {
+			t.Errorf("group %s still holds its monitors after loading", g.Name)
+		}
+	}
+}
+
+func TestMergeMonitorList(t *testing.T) {
+	eighty, zero := 80.0, 0.0
+	base := []MonitorConfig{
+		{Name: "API", Group: "Web", URL: "https://api.example.com", Timeout: 60, Threshold: &eighty, Tags: []TagConfig{{Name: "env"}}},
+		{Name: "DB", Group: "Web", Hostname: "db"},
+	}
+	add := []MonitorConfig{
+		{Name: "API", Group: "Web", Timeout: 30},
+		{Name: "API", Group: "Other", URL: "https://other.example.com"},
+		{Name: "DB", Group: "Web", Threshold: &zero},
+		{Name: "Cache", Group: "Web", Hostname: "cache"},
+	}
+
+	merged := mergeMonitorList(base, add, monitorKey)
+
+	var keys []string
+	for _, m := range merged {
+		keys = append(keys, monitorKey(m))
+	}
+	wantKeys := []string{"API|Web", "DB|Web", "API|Other", "Cache|Web"}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Fatalf("merged monitors = %v, want %v", keys, wantKeys)
+	}
+
+	api := merged[0]
+	if api.Timeout != 30 {
+		t.Errorf("API timeout = %d, want the overlay's 30", api.Timeout)
+	}
+	if api.URL != "https://api.example.com" || api.Threshold == nil || *api.Threshold != 80 || len(api.Tags) != 1 {
+		t.Errorf("API lost base fields the overlay didn't set: %+v", api)
+	}
+	if db := merged[1]; db.Hostname != "db" || db.Threshold == nil || *db.Threshold != 0 {
+		t.Errorf("DB = %+v, want hostname kept and the explicit threshold 0 applied", db)
+	}
+	if merged[2].URL != "https://other.example.com" || merged[3].Hostname != "cache" {
+		t.Errorf("appended monitors = %+v, %+v", merged[2], merged[3])
+	}
+}
+
+func TestMergeLegacyMonitorsByName(t *testing.T) {
+	base := []MonitorConfig{{Name: "Web", Type: "http", URL: "https://www.example.com", Group: "A"}}
+	add := []MonitorConfig{{Name: "Web", Group: "B", Port: 8080}}
+
+	merged := mergeMonitorList(base, add, legacyMonitorKey)
+	if len(merged) != 1 {
+		t.Fatalf("got %d legacy monitors, want the overlay merged into the one named Web", len(merged))
+	}
+	if m := merged[0]; m.Type != "http" || m.URL != "https://www.example.com" || m.Group != "B" || m.Port != 8080 {
+		t.Errorf("merged legacy monitor = %+v", m)
+	}
+}
+
+func TestResolveMetricsThresholdDefaults(t *testing.T) {
+	set := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name    string
+		monitor MonitorConfig
+		global  ThresholdConfig
+		want    float64
+	}{
+		{"per-metric global", MonitorConfig{Name: "CPU Usage"}, ThresholdConfig{CPU: set(75), Default: set(60)}, 75},
+		{"global default without a per-metric value", MonitorConfig{Name: "CPU Usage"}, ThresholdConfig{Default: set(60)}, 60},
+		{"global default for metrics without a section", MonitorConfig{Name: "Load 5"}, ThresholdConfig{CPU: set(75), Default: set(4)}, 4},
+		{"built-in default", MonitorConfig{Name: "Memory"}, ThresholdConfig{}, DefaultThreshold},
+		{"built-in disk default", MonitorConfig{Name: "Root Disk"}, ThresholdConfig{}, DefaultDiskThreshold},
+		{"disk global wins over default", MonitorConfig{Name: "Root Disk"}, ThresholdConfig{Disk: set(95), Default: set(60)}, 95},
+		{"zero global kept", MonitorConfig{Name: "CPU Usage"}, ThresholdConfig{CPU: set(0), Default: set(60)}, 0},
+		{"zero global default kept", MonitorConfig{Name: "Load 5"}, ThresholdConfig{Default: set(0)}, 0},
+		{"explicit threshold kept", MonitorConfig{Name: "CPU Usage", Threshold: set(50)}, ThresholdConfig{CPU: set(75), Default: set(60)}, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.monitor
+			m.ResolveMetrics(&Config{GlobalThresholds: tt.global})
+			if m.Threshold == nil || *m.Threshold != tt.want {
+				t.Errorf("threshold = %v, want %v", m.Threshold, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveMetricsDiskFilesystem(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"Root Disk", "/"},
+		{"Data Disk", ""},
+		{"Disk Usage", ""},
+	}
+	for _, tt := range tests {
+		m := MonitorConfig{Name: tt.name}
+		m.ResolveMetrics(&Config{})
+		if m.Metric != "disk" || m.Field != "used_percent" {
+			t.Errorf("%s: metric %q field %q, want disk used_percent", tt.name, m.Metric, m.Field)
+		}
+		if m.Filesystem != tt.want {
+			t.Errorf("%s: filesystem = %q, want %q", tt.name, m.Filesystem, tt.want)
+		}
+	}
+
+	m := MonitorConfig{Name: "Data Disk", Filesystem: "/srv"}
+	m.ResolveMetrics(&Config{})
+	if m.Filesystem != "/srv" {
+		t.Errorf("explicit filesystem replaced by %q", m.Filesystem)
+	}
+}
+
+func TestLoadRejectsDuplicatesWithinOneFile(t *testing.T) {
+	base := `version: "2"
+uptime_kuma_url: http://kuma:3001
+groups:
+  - name: Web
+    http_monitors:
+      - name: API
+        url: http://api:8080
+`
+	// Repeating a monitor of the base file in an overlay changes it
+	dir := writeConfig(t, map[string]string{
+		"config.yaml": base,
+		"config.prod.yaml": `http_monitors:
+  - name: API
+    group: Web
+    url: https://api.example.com
+`,
+	})
+	cfg, err := LoadMergedConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.HTTPMonitors) != 1 || cfg.HTTPMonitors[0].URL != "https://api.example.com" {
+		t.Errorf("http monitors = %+v, want API overlaid by config.prod.yaml", cfg.HTTPMonitors)
+	}
+
+	// Defining it twice in the same overlay is a mistake, nested or not
+	dir = writeConfig(t, map[string]string{
+		"config.yaml": base,
+		"config.prod.yaml": `groups:
+  - name: Web
+    http_monitors:
+      - name: API
+        url: https://api.example.com
+http_monitors:
+  - name: API
+    group: Web
+    url: https://api-2.example.com
+`,
+	})
+	_, err = LoadMergedConfig(dir)
+	if err == nil || !strings.Contains(err.Error(), `"API" in group "Web" (http in config.prod.yaml, http in config.prod.yaml)`) {
+		t.Errorf("overlay defining API twice: error = %v, want a duplicate naming config.prod.yaml twice", err)
+	}
+}
+
+func TestMergeKeepsZeroGlobalThresholds(t *testing.T) {
+	set := func(v float64) *float64 { return &v }
+	base := Config{GlobalThresholds: ThresholdConfig{CPU: set(80), Disk: set(85)}}
+	add := Config{GlobalThresholds: ThresholdConfig{CPU: set(0)}}
+
+	got := mergeConfigs(base, add).GlobalThresholds
+	if got.CPU == nil || *got.CPU != 0 {
+		t.Errorf("cpu threshold = %v, want the overlay's 0", got.CPU)
+	}
+	if got.Disk == nil || *got.Disk != 85 {
+		t.Errorf("disk threshold = %v, want 85 kept from the base", got.Disk)
+	}
+	if got.Default != nil {
+		t.Errorf("default threshold = %v, want it unset", *got.Default)
+	}
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envRefPattern matches $$ (a literal $), ${VAR}, ${VAR:-default} and $VAR.
+var envRefPattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// unmarshalConfig decodes a config file, expanding environment variable references in
+// string values first. Unquoted values are re-resolved after expansion so that e.g.
+// `interval: ${CHECK_INTERVAL}` still decodes as a number.
+func unmarshalConfig(data []byte, cfg *Config) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	if len(root.Content) == 0 {
+		return nil // empty file
+	}
+
+	if err := expandEnvNode(&root); err != nil {
+		return err
+	}
+	return root.Decode(cfg)
+}
+
+// expandEnvNode expands environment references in every scalar below node.
+func expandEnvNode(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "$") {
+		expanded, err := expandEnv(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		node.Value = expanded
+		if node.Style == 0 {
+			node.Tag = "" // let plain scalars resolve to their expanded type
+		}
+	}
+
+	for _, child := range node.Content {
+		if err := expandEnvNode(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandEnv replaces ${VAR}, $VAR and ${VAR:-default} with values from the process
+// environment. Unset variables without a default are an error; $$ yields a literal $.
+func expandEnv(s string) (string, error) {
+	var missing []string
+
+	expanded := envRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref == "$$" {
+			return "$"
+		}
+
+		m := envRefPattern.FindStringSubmatch(ref)
+		name := m[1]
+		if name == "" {
+			name = m[4]
+		}
+
+		value, ok := os.LookupEnv(name)
+		if m[2] != "" && value == "" {
+			return m[3] // ${VAR:-default} also applies when VAR is set but empty
+		}
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set (use ${%s:-default} for a fallback)", strings.Join(missing, ", "), missing[0])
+	}
+	return expanded, nil
+}
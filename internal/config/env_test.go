@@ -0,0 +1,120 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("TEST_HOST", "kuma")
+	t.Setenv("TEST_PORT", "3001")
+	t.Setenv("TEST_EMPTY", "")
+
+	tests := []struct {
+		in, want string
+	}{
+		{"http://${TEST_HOST}:${TEST_PORT}", "http://kuma:3001"},
+		{"http://$TEST_HOST:$TEST_PORT/", "http://kuma:3001/"},
+		{"${TEST_MISSING:-fallback}", "fallback"},
+		{"${TEST_HOST:-fallback}", "kuma"},
+		{"${TEST_EMPTY:-fallback}", "fallback"},
+		{"${TEST_MISSING:-}", ""},
+		{"${TEST_EMPTY}", ""},
+		{"pa$$word", "pa$word"},
+		{"$${TEST_HOST}", "${TEST_HOST}"},
+		{"$$$TEST_HOST", "$kuma"},
+		{"no references", "no references"},
+		{"costs 5$", "costs 5$"},
+	}
+	for _, tt := range tests {
+		got, err := expandEnv(tt.in)
+		if err != nil {
+			t.Errorf("expandEnv(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("expandEnv(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExpandEnvUnsetVariable(t *testing.T) {
+	for _, in := range []string{"${TEST_MISSING}", "$TEST_MISSING", "http://${TEST_MISSING}/${TEST_OTHER_MISSING}"} {
+		got, err := expandEnv(in)
+		if err == nil {
+			t.Errorf("expandEnv(%q) = %q, want an error for the unset variable", in, got)
+			continue
+		}
+		if !strings.Contains(err.Error(), "TEST_MISSING") || !strings.Contains(err.Error(), "is not set") {
+			t.Errorf("expandEnv(%q) error = %v, want it to name the variable", in, err)
+		}
+	}
+}
+
+func TestLoadExpandsEnvInValues(t *testing.T) {
+	t.Setenv("TEST_KUMA_URL", "http://kuma:3001")
+	t.Setenv("TEST_INTERVAL", "45")
+	dir := writeConfig(t, map[string]string{"config.yaml": `version: "2"
+uptime_kuma_url: ${TEST_KUMA_URL}
+interval: ${TEST_INTERVAL}
+password: "pa$$word"
+`})
+
+	cfg, err := LoadMergedConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.UptimeKumaURL != "http://kuma:3001" || cfg.Interval != 45 || cfg.Password != "pa$word" {
+		t.Errorf("loaded url %q, interval %d, password %q", cfg.UptimeKumaURL, cfg.Interval, cfg.Password)
+	}
+
+	dir = writeConfig(t, map[string]string{"config.yaml": "uptime_kuma_url: ${TEST_MISSING}\n"})
+	if _, err := LoadMergedConfig(dir); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("load with an unset variable: error = %v, want one pointing at line 1", err)
+	}
+}
+
+func TestLoadExpandsEnvInOverlay(t *testing.T) {
+	t.Setenv("TEST_API_HOST", "api.example.com")
+	base := `version: "2"
+uptime_kuma_url: http://kuma:3001
+groups:
+  - name: Web
+`
+	dir := writeConfig(t, map[string]string{
+		"config.yaml": base,
+		"config.prod.yaml": `groups:
+  - name: Web
+    http_monitors:
+      - name: API
+        url: https://${TEST_API_HOST}/health
+        timeout: ${TEST_TIMEOUT:-15}
+`,
+	})
+
+	cfg, err := LoadMergedConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.HTTPMonitors) != 1 {
+		t.Fatalf("merged %d http monitors, want the API monitor from the overlay", len(cfg.HTTPMonitors))
+	}
+	m := cfg.HTTPMonitors[0]
+	if m.Group != "Web" || m.URL != "https://api.example.com/health" || m.Timeout != 15 {
+		t.Errorf("merged monitor group %q, url %q, timeout %d", m.Group, m.URL, m.Timeout)
+	}
+
+	dir = writeConfig(t, map[string]string{
+		"config.yaml": base,
+		"config.prod.yaml": `groups:
+  - name: Web
+    http_monitors:
+      - name: API
+        url: https://${TEST_MISSING}/health
+`,
+	})
+	_, err = LoadMergedConfig(dir)
+	if err == nil || !strings.Contains(err.Error(), "config.prod.yaml") || !strings.Contains(err.Error(), "line 5") {
+		t.Errorf("overlay with an unset variable: error = %v, want one naming config.prod.yaml and line 5", err)
+	}
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CurrentSchemaVersion is the config layout this agent reads. Files with an older
+// version: are migrated in memory when loaded; the files themselves are never rewritten.
+const CurrentSchemaVersion = 2
+
+// migrations[i] upgrades a config file from schema version i+1 to i+2, returning a note
+// per change for the log.
+var migrations = []func(c *Config) []string{
+	migrateLegacyMonitors,
+}
+
+// legacySections maps the type of a legacy monitors entry to the section it moves to.
+var legacySections = map[string]string{"port": "tcp"}
+
+// schemaVersion returns the major version of c's version: field. Files without one are
+// read as version 1, the layout before versioning.
+func (c *Config) schemaVersion() (int, error) {
+	if c.SchemaVersion == "" {
+		return 1, nil
+	}
+	major, _, _ := strings.Cut(c.SchemaVersion, ".") // "1.0" was the documented form
+	version, err := strconv.Atoi(major)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid version %q: want a schema version such as %d", c.SchemaVersion, CurrentSchemaVersion)
+	}
+	return version, nil
+}
+
+// migrate upgrades the config parsed from file to CurrentSchemaVersion, recording what
+// changed for Migrations. A file newer than this agent supports is rejected rather than
+// half understood.
+func (c *Config) migrate(file string) error {
+	version, err := c.schemaVersion()
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	if version > CurrentSchemaVersion {
+		return fmt.Errorf("%s uses config schema version %d, but this agent supports up to version %d; upgrade the agent", file, version, CurrentSchemaVersion)
+	}
+
+	for v := version; v < CurrentSchemaVersion; v++ {
+		for _, note := range migrations[v-1](c) {
+			c.migrations = append(c.migrations, fmt.Sprintf("%s (version %d to %d): %s", filepath.Base(file), v, v+1, note))
+		}
+	}
+	c.SchemaVersion = strconv.Itoa(CurrentSchemaVersion)
+	return nil
+}
+
+// Migrations lists the changes made while migrating the config files to
+// CurrentSchemaVersion; none when every file is current.
+func (c *Config) Migrations() []string {
+	return c.migrations
+}
+
+// migrateLegacyMonitors moves entries of the deprecated monitors list into the typed
+// section of their type. Both are matched by name alone and created under the first
+// group, so provisioning is unchanged. Entries with a group stay put: the legacy list
+// ignores it, and moving them would start placing them in that group.
+func migrateLegacyMonitors(c *Config) []string {
+	sections := make(map[string]*[]MonitorConfig)
+	for _, section := range c.typedSections() {
+		sections[section.Type] = section.Monitors
+	}
+
+	var notes []string
+	var kept []MonitorConfig
+	for _, m := range c.Monitors {
+		typ := m.Type
+		if mapped, ok := legacySections[typ]; ok {
+			typ = mapped
+		}
+		section, ok := sections[typ]
+		if !ok || m.Group != "" {
+			kept = append(kept, m)
+			continue
+		}
+		m.Type = typ
+		*section = append(*section, m)
+		notes = append(notes, fmt.Sprintf("moved monitor %q from monitors to %s_monitors", m.Name, typ))
+	}
+	c.Monitors = kept
+	return notes
+}
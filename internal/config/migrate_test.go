@@ -0,0 +1,98 @@
+package config
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMigrateLegacyMonitors(t *testing.T) {
+	cfg := &Config{
+		HTTPMonitors: []MonitorConfig{{Name: "Existing"}},
+		Monitors: []MonitorConfig{
+			{Name: "Web", Type: "http"},
+			{Name: "SSH", Type: "port"},
+			{Name: "Grouped", Type: "push", Group: "Servers"},
+			{Name: "Odd", Type: "unknown"},
+		},
+	}
+
+	notes := migrateLegacyMonitors(cfg)
+
+	wantNotes := []string{
+		`moved monitor "Web" from monitors to http_monitors`,
+		`moved monitor "SSH" from monitors to tcp_monitors`,
+	}
+	if !reflect.DeepEqual(notes, wantNotes) {
+		t.Errorf("notes = %q, want %q", notes, wantNotes)
+	}
+	if got := names(cfg.HTTPMonitors); !reflect.DeepEqual(got, []string{"Existing", "Web"}) {
+		t.Errorf("http_monitors = %v, want the legacy entry appended", got)
+	}
+	if len(cfg.TCPMonitors) != 1 || cfg.TCPMonitors[0].Name != "SSH" || cfg.TCPMonitors[0].Type != "tcp" {
+		t.Errorf("tcp_monitors = %+v, want SSH moved from type port", cfg.TCPMonitors)
+	}
+	if got := names(cfg.Monitors); !reflect.DeepEqual(got, []string{"Grouped", "Odd"}) {
+		t.Errorf("monitors = %v, want the grouped and unknown entries kept", got)
+	}
+}
+
+func TestLoadMigratesVersion1(t *testing.T) {
+	dir := writeConfig(t, map[string]string{"config.yaml": `uptime_kuma_url: http://kuma:3001
+monitors:
+  - name: Web
+    type: http
+    url: https://www.example.com
+`})
+
+	cfg, err := LoadMergedConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SchemaVersion != strconv.Itoa(CurrentSchemaVersion) {
+		t.Errorf("schema version = %q, want %d", cfg.SchemaVersion, CurrentSchemaVersion)
+	}
+	if len(cfg.Monitors) != 0 || !reflect.DeepEqual(names(cfg.HTTPMonitors), []string{"Web"}) {
+		t.Errorf("legacy monitor not migrated: monitors=%v http_monitors=%v", names(cfg.Monitors), names(cfg.HTTPMonitors))
+	}
+	want := []string{`config.yaml (version 1 to 2): moved monitor "Web" from monitors to http_monitors`}
+	if !reflect.DeepEqual(cfg.Migrations(), want) {
+		t.Errorf("Migrations = %q, want %q", cfg.Migrations(), want)
+	}
+}
+
+func TestLoadSchemaVersions(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr string
+	}{
+		{`"1.0"`, ""},
+		{strconv.Itoa(CurrentSchemaVersion), ""},
+		{strconv.Itoa(CurrentSchemaVersion + 1), "upgrade the agent"},
+		{`"next"`, "invalid version"},
+		{"0", "invalid version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			dir := writeConfig(t, map[string]string{"config.yaml": "version: " + tt.version + "\nuptime_kuma_url: http://kuma:3001\n"})
+			_, err := LoadMergedConfig(dir)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// names returns the names of monitors.
+func names(monitors []MonitorConfig) []string {
+	var out []string
+	for _, m := range monitors {
+		out = append(out, m.Name)
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package config
+
+import "encoding/json"
+
+// PushSpec holds what push-metric needs from the config for one push monitor, beyond the
+// field, threshold, operator and unit it gets as flags. The Telegraf generator renders it
+// into the exec command as --spec, so push-metric, which Telegraf starts as a fresh
+// process for every flush, doesn't load the whole config.
+type PushSpec struct {
+	UptimeKumaURL     string   `json:"url"`
+	CACert            string   `json:"ca_cert,omitempty"`
+	Interval          int      `json:"interval,omitempty"`
+	AllowStatusScript bool     `json:"allow_status_scripts,omitempty"`
+	LogDirectory      string   `json:"log_directory,omitempty"`
+	Metric            string   `json:"metric"`
+	Fields            []string `json:"fields,omitempty"`
+	PingField         string   `json:"ping_field,omitempty"`
+	Aggregation       string   `json:"aggregation,omitempty"`
+	MinThreshold      *float64 `json:"min_threshold,omitempty"`
+	MaxThreshold      *float64 `json:"max_threshold,omitempty"`
+	RecoveryThreshold *float64 `json:"recovery_threshold,omitempty"`
+	PushEpsilon       *float64 `json:"push_epsilon,omitempty"`
+	StatusScript      string   `json:"status_script,omitempty"`
+}
+
+// NewPushSpec returns the spec of push monitor m, whose metrics must already be resolved
+// (see ResolveMetrics). logDirectory is the log directory as push-metric sees it.
+func NewPushSpec(cfg *Config, m *MonitorConfig, logDirectory string) PushSpec {
+	return PushSpec{
+		UptimeKumaURL:     cfg.UptimeKumaURL,
+		CACert:            cfg.CACert,
+		Interval:          cfg.Interval,
+		AllowStatusScript: cfg.Agent.AllowStatusScript != nil && *cfg.Agent.AllowStatusScript,
+		LogDirectory:      logDirectory,
+		Metric:            m.Metric,
+		Fields:            m.Fields,
+		PingField:         m.PingField,
+		Aggregation:       m.Aggregation,
+		MinThreshold:      m.MinThreshold,
+		MaxThreshold:      m.MaxThreshold,
+		RecoveryThreshold: m.RecoveryThreshold,
+		PushEpsilon:       m.PushEpsilon,
+		StatusScript:      m.StatusScript,
+	}
+}
+
+// ParsePushSpec decodes a --spec value into the config and push monitor name in group it
+// describes. The config holds only the settings push-metric reads.
+func ParsePushSpec(data, name, group string) (*Config, MonitorConfig, error) {
+	var s PushSpec
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		return nil, MonitorConfig{}, err
+	}
+
+	cfg := &Config{
+		UptimeKumaURL: s.UptimeKumaURL,
+		CACert:        s.CACert,
+		Interval:      s.Interval,
+	}
+	cfg.Agent.AllowStatusScript = &s.AllowStatusScript
+	cfg.Agent.Logging.InternalLogDirectory = s.LogDirectory
+
+	m := MonitorConfig{
+		Name:              name,
+		Group:             group,
+		Type:              "push",
+		Metric:            s.Metric,
+		Fields:            s.Fields,
+		PingField:         s.PingField,
+		Aggregation:       s.Aggregation,
+		MinThreshold:      s.MinThreshold,
+		MaxThreshold:      s.MaxThreshold,
+		RecoveryThreshold: s.RecoveryThreshold,
+		PushEpsilon:       s.PushEpsilon,
+		StatusScript:      s.StatusScript,
+	}
+	return cfg, m, nil
+}
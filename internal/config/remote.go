@@ -0,0 +1,184 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// RemoteIndexFile sits next to a remote config and lists its overlays, one file name
+	// (config.*.yaml or config.*.json) per line. Without it the config has no overlays.
+	RemoteIndexFile = "config.index"
+
+	// remoteManifestFile records the files the last fetch wrote into the mirror
+	// directory, so overlays dropped from the index are removed and nothing else is.
+	remoteManifestFile = ".remote-config"
+)
+
+// errNotFound marks a 404 from the config server.
+var errNotFound = errors.New("not found")
+
+// IsRemote reports whether path is an http:// or https:// URL rather than a local file.
+func IsRemote(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// FetchRemote downloads the config at rawURL, plus the overlays listed in the
+// RemoteIndexFile next to it, into dir. The config is saved as config.yaml (config.json
+// for a .json URL), so dir loads with LoadMergedConfig like a local config directory and
+// tokens.yaml and the run cache live there too. Files are only rewritten when their content
+// changed, which the returned bool reports; header is sent with every request.
+func FetchRemote(ctx context.Context, client *http.Client, rawURL string, header http.Header, dir string) (bool, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false, errors.New("invalid config URL") // parse errors repeat the URL, password included
+	}
+	if u.Host == "" {
+		return false, fmt.Errorf("invalid config URL %q: no host", redacted(u))
+	}
+
+	baseName := "config.yaml"
+	if strings.EqualFold(path.Ext(u.Path), ".json") {
+		baseName = "config.json"
+	}
+	files := make(map[string][]byte)
+	if files[baseName], err = fetch(ctx, client, u, header); err != nil {
+		return false, err
+	}
+
+	index, err := fetch(ctx, client, sibling(u, RemoteIndexFile), header)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return false, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(index))
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+		if !isOverlayName(name) {
+			return false, fmt.Errorf("%s: %q is not a config.*.yaml or config.*.json file name", RemoteIndexFile, name)
+		}
+		if files[name], err = fetch(ctx, client, sibling(u, name), header); err != nil {
+			return false, err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return false, fmt.Errorf("failed to create config directory %s: %w", dir, err)
+	}
+	changed := false
+	for name, data := range files {
+		target := filepath.Join(dir, name)
+		if current, err := os.ReadFile(target); err == nil && bytes.Equal(current, data) {
+			continue
+		}
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return false, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		changed = true
+	}
+
+	// Drop what the previous fetch wrote and this one didn't, e.g. a removed overlay
+	manifestPath := filepath.Join(dir, remoteManifestFile)
+	previous, _ := os.ReadFile(manifestPath)
+	for _, name := range strings.Fields(string(previous)) {
+		if _, ok := files[name]; ok || (name != "config.yaml" && name != "config.json" && !isOverlayName(name)) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, fmt.Errorf("failed to remove stale %s: %w", name, err)
+		}
+		changed = true
+	}
+	manifest := []byte(strings.Join(slices.Sorted(maps.Keys(files)), "\n") + "\n")
+	if !bytes.Equal(previous, manifest) {
+		if err := os.WriteFile(manifestPath, manifest, 0o644); err != nil {
+			return false, fmt.Errorf("failed to write %s: %w", manifestPath, err)
+		}
+	}
+	return changed, nil
+}
+
+// fetch returns the body of a GET of u, or an error wrapping errNotFound for a 404.
+func fetch(ctx context.Context, client *http.Client, u *url.URL, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The client error repeats the URL; keep credentials in it out of the message
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("failed to fetch %s: %w", redacted(u), err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("failed to fetch %s: %w", redacted(u), errNotFound)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to fetch %s: %s", redacted(u), resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", redacted(u), err)
+	}
+	return data, nil
+}
+
+// sibling returns the URL of file name next to u, keeping u's query (e.g. an access
+// token) for servers that authenticate that way.
+func sibling(u *url.URL, name string) *url.URL {
+	s := u.ResolveReference(&url.URL{Path: name})
+	s.RawQuery = u.RawQuery
+	return s
+}
+
+// RedactedURL returns rawURL for messages, with its password and query (either may hold
+// credentials) masked.
+func RedactedURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid URL>"
+	}
+	return redacted(u)
+}
+
+func redacted(u *url.URL) string {
+	if u.RawQuery != "" {
+		masked := *u
+		masked.RawQuery = "xxxxx"
+		return masked.Redacted()
+	}
+	return u.Redacted()
+}
+
+// isOverlayName reports whether name is a bare config.*.yaml or config.*.json file name.
+func isOverlayName(name string) bool {
+	for _, pattern := range []string{"config.*.yaml", "config.*.json"} {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+// TokensFile holds the push tokens fetched from Uptime Kuma. It lives next to config.yaml
+// and is merged over the config files, so provisioning never rewrites hand-written config.
+const TokensFile = "tokens.yaml"
+
+const tokensHeader = "# Push tokens fetched from Uptime Kuma by uptime-kuma-agent. Do not edit.\n"
+
+type pushTokenEntry struct {
+	Name  string `yaml:"name"`
+	Group string `yaml:"group,omitempty"`
+	Token string `yaml:"token"`
+}
+
+type tokensDocument struct {
+	PushTokens []pushTokenEntry `yaml:"push_tokens"`
+}
+
+// TokensPath returns the tokens file belonging to the loaded config.
+func (c *Config) TokensPath() string {
+	dir := "/config"
+	if len(c.SourceFiles) > 0 {
+		dir = filepath.Dir(c.SourceFiles[0])
+	}
+	return filepath.Join(dir, TokensFile)
+}
+
+// InlinePushTokens lists the push monitors whose token is still set in a config file
+// rather than only in the tokens file.
+func (c *Config) InlinePushTokens() []string {
+	return c.inlineTokens
+}
+
+// loadTokens reads the tokens file at path, keyed by monitor name|group. A missing file
+// yields no tokens.
+func loadTokens(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var doc tokensDocument
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", path, err)
+	}
+	tokens := make(map[string]string, len(doc.PushTokens))
+	for _, e := range doc.PushTokens {
+		tokens[e.Name+"|"+e.Group] = e.Token
+	}
+	return tokens, nil
+}
+
+// applyTokens sets push tokens from the tokens file, which wins over inline push_token
+// values since it reflects the last provisioning run. Inline tokens are remembered so
+// provisioning can point out they're no longer needed.
+func (c *Config) applyTokens(tokens map[string]string) {
+	c.inlineTokens = nil
+	for _, m := range c.pushMonitors() {
+		if m.PushToken != "" {
+			c.inlineTokens = append(c.inlineTokens, m.Name)
+		}
+		if token, ok := tokens[m.Name+"|"+m.Group]; ok && token != "" {
+			m.PushToken = token
+		}
+	}
+}
+
+// FindPushMonitor returns the push monitor name in group, or nil.
+func (c *Config) FindPushMonitor(name, group string) *MonitorConfig {
+	for _, m := range c.pushMonitors() {
+		if m.Name == name && m.Group == group {
+			return m
+		}
+	}
+	return nil
+}
+
+// pushMonitors returns pointers to every push monitor, typed and legacy.
+func (c *Config) pushMonitors() []*MonitorConfig {
+	var monitors []*MonitorConfig
+	for i := range c.PushMonitors {
+		monitors = append(monitors, &c.PushMonitors[i])
+	}
+	for i := range c.Monitors {
+		if c.Monitors[i].Type == "push" {
+			monitors = append(monitors, &c.Monitors[i])
+		}
+	}
+	return monitors
+}
+
+// SaveTokens writes the push tokens of cfg to its tokens file, reporting whether the file
+// changed. Tokens still set inline are included, which migrates them on the first run.
+func SaveTokens(cfg *Config) (bool, error) {
+	path := cfg.TokensPath()
+	stored, err := loadTokens(path)
+	if err != nil {
+		return false, err
+	}
+
+	var doc tokensDocument
+	changed := false
+	seen := make(map[string]bool)
+	for _, m := range cfg.pushMonitors() {
+		key := m.Name + "|" + m.Group
+		if m.PushToken == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		doc.PushTokens = append(doc.PushTokens, pushTokenEntry{Name: m.Name, Group: m.Group, Token: m.PushToken})
+		if stored[key] != m.PushToken {
+			changed = true
+		}
+	}
+	if !changed && len(doc.PushTokens) == len(stored) {
+		return false, nil
+	}
+
+	sort.Slice(doc.PushTokens, func(i, j int) bool {
+		a, b := doc.PushTokens[i], doc.PushTokens[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		return a.Name < b.Name
+	})
+	data, err := yaml.Marshal(&doc)
+	if err != nil {
+		return false, err
+	}
+	// Replace the file atomically, so an interrupted save can't truncate it and lose
+	// every token
+	if err := util.WriteFileAtomic(path, append([]byte(tokensHeader), data...), 0o600); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return true, nil
+}
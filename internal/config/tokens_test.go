@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const commentedConfig = `# Managed in git; keep the comments.
+version: "2"
+uptime_kuma_url: http://kuma:3001 # local instance
+
+groups:
+  - name: Servers
+    push_monitors:
+      # disk alert for the data volume
+      - name: Disk
+        metric: disk
+        filesystem: /data
+        push_token: inline-token # set before tokens.yaml existed
+`
+
+// writeConfig writes files (name to content) into a new directory and returns it.
+func writeConfig(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSaveTokensLeavesConfigUntouched(t *testing.T) {
+	dir := writeConfig(t, map[string]string{"config.yaml": commentedConfig})
+
+	cfg, err := LoadMergedConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := cfg.FindPushMonitor("Disk", "Servers")
+	if m == nil {
+		t.Fatal("push monitor Disk not found")
+	}
+	m.PushToken = "fetched-token"
+
+	saved, err := SaveTokens(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !saved {
+		t.Error("SaveTokens reported no change for a new token")
+	}
+	if info, err := os.Stat(filepath.Join(dir, TokensFile)); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("%s mode = %v (%v), want 0600", TokensFile, info.Mode().Perm(), err)
+	}
+	if left, _ := filepath.Glob(filepath.Join(dir, TokensFile+".tmp-*")); len(left) != 0 {
+		t.Errorf("temp files left behind: %v", left)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != commentedConfig {
+		t.Errorf("config.yaml changed by saving tokens:\n%s", data)
+	}
+
+	reloaded, err := LoadMergedConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.FindPushMonitor("Disk", "Servers").PushToken; got != "fetched-token" {
+		t.Errorf("reloaded push token = %q, want the one from %s", got, TokensFile)
+	}
+
+	saved, err = SaveTokens(reloaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved {
+		t.Error("SaveTokens rewrote an unchanged tokens file")
+	}
+}
@@ -0,0 +1,530 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// metricFields lists the Telegraf fields push monitors can be mapped to, per metric the
+// agent generates inputs for.
+var metricFields = map[string][]string{
+	"cpu": {
+		"usage_user", "usage_system", "usage_idle", "usage_iowait", "usage_irq", "usage_softirq",
+		"usage_nice", "usage_steal", "usage_guest", "usage_guest_nice",
+	},
+	"mem": {
+		"active", "available", "available_percent", "buffered", "cached", "commit_limit",
+		"committed_as", "dirty", "free", "high_free", "high_total", "huge_page_size",
+		"huge_pages_free", "huge_pages_total", "inactive", "mapped", "page_tables", "shared",
+		"slab", "sreclaimable", "sunreclaim", "swap_cached", "swap_free", "swap_total", "total",
+		"used", "used_percent", "vmalloc_chunk", "vmalloc_total", "vmalloc_used", "write_back",
+		"write_back_tmp",
+	},
+	"disk": {
+		"free", "total", "used", "used_percent", "inodes_free", "inodes_total", "inodes_used",
+		"inodes_used_percent",
+	},
+	"system": {"load1", "load5", "load15", "n_cpus", "n_users", "uptime"},
+	"temp":   {"temp"},
+	"swap":   {"free", "total", "used", "used_percent", "in", "out"},
+	"net": {
+		"bytes_sent", "bytes_recv", "packets_sent", "packets_recv", "err_in", "err_out",
+		"drop_in", "drop_out", "speed",
+	},
+	"docker_container_cpu": {
+		"usage_percent", "usage_total", "usage_system", "usage_in_usermode", "usage_in_kernelmode",
+		"throttling_periods", "throttling_throttled_periods", "throttling_throttled_time",
+	},
+	"docker_container_mem": {
+		"usage_percent", "usage", "limit", "max_usage", "active_anon", "inactive_anon",
+		"active_file", "inactive_file", "cache", "rss", "unevictable",
+	},
+}
+
+// ThresholdOperators are the push monitor operators; the monitor is down when
+// "value <operator> threshold" holds.
+var ThresholdOperators = []string{"gt", "gte", "lt", "lte", "eq"}
+
+// Aggregations reduce multiple matching push-metric values (e.g. per-core series) to one.
+var Aggregations = []string{"last", "avg", "max", "min", "sum"}
+
+// mqttSchemes are the broker URL schemes Uptime Kuma's MQTT monitor connects with.
+var mqttSchemes = []string{"mqtt", "mqtts", "ws", "wss"}
+
+// rawBaseSettings are the monitor fields the agent sets itself, so raw monitors can't
+// override them through settings.
+var rawBaseSettings = []string{"id", "type", "name", "description", "pathName", "parent", "proxyId", "interval", "retryInterval", "resendInterval", "maxretries", "upsideDown", "active", "notificationIDList", "tags"}
+
+// dnsRecordTypes are the record types Uptime Kuma can resolve.
+var dnsRecordTypes = []string{"A", "AAAA", "CAA", "CNAME", "MX", "NS", "PTR", "SOA", "SRV", "TXT"}
+
+// statusPageSlugPattern matches the slugs Uptime Kuma accepts for status pages.
+var statusPageSlugPattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// outputPluginPattern matches Telegraf plugin names such as influxdb_v2.
+var outputPluginPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// tagColorPattern matches the hex colors Uptime Kuma uses for tags.
+var tagColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// Validate checks the merged config for structural problems without contacting Uptime
+// Kuma, returning every problem found (nil when the config is valid).
+func (c *Config) Validate() []string {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.UptimeKumaURL == "" {
+		addf("uptime_kuma_url is required")
+	} else if u, err := url.Parse(c.UptimeKumaURL); err != nil || u.Scheme == "" || u.Host == "" {
+		addf("uptime_kuma_url %q is not a valid URL", c.UptimeKumaURL)
+	}
+	if (c.Username == "") != (c.Password == "") {
+		addf("username and password must be set together (the agent skips login otherwise)")
+	}
+	if c.Interval != 0 && c.Interval < 20 {
+		addf("interval %d is below the Uptime Kuma minimum of 20 seconds", c.Interval)
+	}
+	if c.MaxRetries < 0 {
+		addf("max_retries must not be negative")
+	}
+	for _, t := range []struct {
+		name  string
+		value *float64
+	}{{"cpu", c.GlobalThresholds.CPU}, {"ram", c.GlobalThresholds.RAM}, {"disk", c.GlobalThresholds.Disk}, {"default", c.GlobalThresholds.Default}} {
+		if t.value != nil && (*t.value < 0 || *t.value > 100) {
+			addf("global_thresholds.%s %.2f must be between 0 and 100", t.name, *t.value)
+		}
+	}
+	if c.Agent.PushTokenBytes != 0 && c.Agent.PushTokenBytes < DefaultPushTokenBytes {
+		addf("agent.push_token_bytes %d is below the minimum of %d", c.Agent.PushTokenBytes, DefaultPushTokenBytes)
+	}
+	for _, f := range c.Agent.ManagedFields {
+		if !slices.Contains(KnownManagedFields, strings.ToLower(f)) {
+			addf("agent.managed_fields: unknown field %q (valid: %s)", f, strings.Join(KnownManagedFields, ", "))
+		}
+	}
+
+	switch mode := c.ExecMode(); {
+	case !slices.Contains(ExecModes, mode):
+		addf("agent.exec_mode %q is not one of %s", c.Agent.ExecMode, strings.Join(ExecModes, ", "))
+	case mode == ExecModeBinary:
+		if err := CheckExecBinary(c.BinaryPath()); err != nil {
+			addf("agent.binary_path: %v", err)
+		}
+	}
+
+	outputs := make(map[string]bool)
+	for _, o := range c.Agent.Outputs {
+		switch {
+		case o.Plugin == "":
+			addf("agent.outputs: plugin is required (got name=%q)", o.Name)
+			continue
+		case outputs[strings.ToLower(o.FileName())]:
+			addf("agent.outputs: duplicate output %q (set name to tell them apart)", o.FileName())
+		}
+		outputs[strings.ToLower(o.FileName())] = true
+		if _, err := o.TOML(); err != nil {
+			addf("agent.outputs: %v", err)
+		}
+	}
+
+	notifications := make(map[string]bool)
+	for _, n := range c.Notifications {
+		if n.Name == "" || n.Type == "" {
+			addf("notifications: name and type are required (got name=%q type=%q)", n.Name, n.Type)
+		} else if notifications[n.Name] {
+			addf("notifications: duplicate notification %q", n.Name)
+		}
+		notifications[n.Name] = true
+	}
+
+	proxies := make(map[string]bool)
+	for _, p := range c.Proxies {
+		if p.Name == "" {
+			addf("proxies: proxy without a name (host %q)", p.Host)
+		} else if proxies[p.Name] {
+			addf("proxies: duplicate proxy %q", p.Name)
+		}
+		proxies[p.Name] = true
+		if p.Host == "" {
+			addf("proxy %q: host is required", p.Name)
+		}
+		if p.Port <= 0 || p.Port > 65535 {
+			addf("proxy %q: port must be between 1 and 65535", p.Name)
+		}
+		if p.Protocol != "" && !slices.Contains(ProxyProtocols, strings.ToLower(p.Protocol)) {
+			addf("proxy %q: protocol %q is not one of %s", p.Name, p.Protocol, strings.Join(ProxyProtocols, ", "))
+		}
+		if p.Password != "" && p.Username == "" {
+			addf("proxy %q: password requires a username", p.Name)
+		}
+	}
+
+	if sp := c.StatusPage; sp != nil {
+		if !statusPageSlugPattern.MatchString(sp.Slug) {
+			addf("status_page.slug %q must be lowercase letters, digits and dashes", sp.Slug)
+		}
+		if sp.Title == "" {
+			addf("status_page.title is required")
+		}
+		if sp.Theme != "" && !slices.Contains(StatusPageThemes, sp.Theme) {
+			addf("status_page.theme %q is not one of %s", sp.Theme, strings.Join(StatusPageThemes, ", "))
+		}
+	}
+
+	maintenance := make(map[string]bool)
+	for _, m := range c.Maintenance {
+		if m.Name == "" {
+			addf("maintenance: window without a name")
+		} else if maintenance[m.Name] {
+			addf("maintenance: duplicate window %q", m.Name)
+		}
+		maintenance[m.Name] = true
+		for _, problem := range m.validate() {
+			addf("maintenance %q: %s", m.Name, problem)
+		}
+	}
+
+	groups := make(map[string]bool)
+	for _, g := range c.Groups {
+		if g.Name == "" {
+			addf("groups: group without a name")
+		} else if groups[g.Name] {
+			addf("groups: duplicate group %q", g.Name)
+		}
+		groups[g.Name] = true
+	}
+
+	seen := make(map[string]string) // name|group -> type of first occurrence
+	for _, m := range c.GetAllMonitors() {
+		m.ResolveMetrics(c) // validate the effective metric, field and threshold
+		label := fmt.Sprintf("%s monitor %q", m.Type, m.Name)
+
+		if m.Name == "" {
+			addf("%s monitor without a name", m.Type)
+		}
+		key := m.Name + "|" + m.Group
+		if first, dup := seen[key]; dup {
+			addf("%s: duplicate name in group %q (also defined as a %s monitor)", label, m.Group, first)
+		} else {
+			seen[key] = m.Type
+		}
+		if m.Group != "" && !groups[m.Group] {
+			addf("%s: group %q is not defined under groups", label, m.Group)
+		}
+		for _, f := range m.ManagedFields {
+			if !slices.Contains(KnownManagedFields, strings.ToLower(f)) {
+				addf("%s: unknown managed field %q", label, f)
+			}
+		}
+
+		if m.Proxy != "" {
+			if m.Type != "http" && m.Type != "keyword" {
+				addf("%s: proxy is only supported for http and keyword monitors", label)
+			} else if !proxies[m.Proxy] {
+				addf("%s: proxy %q is not defined under proxies", label, m.Proxy)
+			}
+		}
+
+		for _, t := range m.Tags {
+			if t.Name == "" {
+				addf("%s: tag without a name", label)
+			} else if t.Color != "" && !tagColorPattern.MatchString(t.Color) {
+				addf("%s: tag %q color %q must be a hex color like #2563EB", label, t.Name, t.Color)
+			}
+		}
+
+		for _, problem := range m.validateType() {
+			addf("%s: %s", label, problem)
+		}
+	}
+
+	return problems
+}
+
+// CheckMetrics checks the effective metric and field of every Telegraf-fed push monitor,
+// so a typo fails the run instead of leaving the monitor pending. An unknown metric is an
+// error listing the supported metric/field pairs; a field the metric doesn't know is
+// returned as a warning, since Telegraf may still report it.
+func (c *Config) CheckMetrics() ([]string, error) {
+	var warnings, unknown []string
+	for _, m := range c.GetAllMonitors() {
+		if m.Type != "push" || m.CustomInput != "" {
+			continue
+		}
+		m.ResolveMetrics(c)
+		if m.Metric == "" {
+			continue // pushed by something other than Telegraf
+		}
+		fields, ok := metricFields[m.Metric]
+		if !ok {
+			unknown = append(unknown, fmt.Sprintf("monitor %q: unknown metric %q", m.Name, m.Metric))
+			continue
+		}
+		if m.Field != "" && !slices.Contains(fields, m.Field) {
+			warnings = append(warnings, fmt.Sprintf("monitor %q: field %q is not a known %s field (known: %s)", m.Name, m.Field, m.Metric, strings.Join(fields, ", ")))
+		}
+	}
+	if len(unknown) == 0 {
+		return warnings, nil
+	}
+
+	metrics := slices.Sorted(maps.Keys(metricFields))
+	supported := make([]string, 0, len(metrics))
+	for _, metric := range metrics {
+		supported = append(supported, fmt.Sprintf("  %s: %s", metric, strings.Join(metricFields[metric], ", ")))
+	}
+	return warnings, fmt.Errorf("%s\nsupported metrics and fields:\n%s", strings.Join(unknown, "\n"), strings.Join(supported, "\n"))
+}
+
+// validateType checks the fields required by the monitor's type.
+func (m *MonitorConfig) validateType() []string {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch m.Type {
+	case "push":
+		if m.Metric == "" {
+			break // pushed by something other than Telegraf
+		}
+		if m.CustomInput != "" {
+			if err := CheckTOML(m.CustomInput); err != nil {
+				addf("custom_input is not valid TOML: %v", err)
+			}
+			if m.Field == "" {
+				addf("custom_input requires a field")
+			}
+			for _, f := range m.Fields {
+				if f == "" {
+					addf("fields must not contain empty names")
+				}
+			}
+			break // custom measurements have their own fields
+		}
+		fields, ok := metricFields[m.Metric]
+		if !ok {
+			addf("unknown metric %q", m.Metric)
+			break
+		}
+		if m.Field == "" {
+			addf("metric %s requires a field", m.Metric)
+		} else if !slices.Contains(fields, m.Field) {
+			addf("field %q is not valid for metric %s", m.Field, m.Metric)
+		}
+		for _, f := range m.Fields {
+			if !slices.Contains(fields, f) {
+				addf("fields: %q is not valid for metric %s", f, m.Metric)
+			}
+		}
+		if m.PingField != "" && !slices.Contains(fields, m.PingField) {
+			addf("ping_field %q is not valid for metric %s", m.PingField, m.Metric)
+		}
+		if m.Metric == "disk" && m.Filesystem == "" {
+			addf("disk metric requires a filesystem")
+		}
+		if strings.HasPrefix(m.Metric, "docker_") && m.ContainerName == "" {
+			addf("metric %s requires a container_name", m.Metric)
+		}
+		if m.Operator != "" && !slices.Contains(ThresholdOperators, strings.ToLower(m.Operator)) {
+			addf("operator %q is not one of %s", m.Operator, strings.Join(ThresholdOperators, ", "))
+		}
+		if m.Aggregation != "" && !slices.Contains(Aggregations, strings.ToLower(m.Aggregation)) {
+			addf("aggregation %q is not one of %s", m.Aggregation, strings.Join(Aggregations, ", "))
+		}
+		if m.MinThreshold != nil && m.MaxThreshold != nil && *m.MinThreshold > *m.MaxThreshold {
+			addf("min_threshold %.2f must not be above max_threshold %.2f", *m.MinThreshold, *m.MaxThreshold)
+		}
+		if m.HasRange() && m.Operator != "" {
+			addf("operator is ignored when min_threshold or max_threshold is set")
+		}
+		if m.PushEpsilon != nil && *m.PushEpsilon < 0 {
+			addf("push_epsilon must not be negative")
+		}
+		if r := m.RecoveryThreshold; r != nil {
+			switch operator := strings.ToLower(m.Operator); {
+			case m.HasRange():
+				addf("recovery_threshold is ignored when min_threshold or max_threshold is set")
+			case operator == "eq":
+				addf("recovery_threshold is not supported with operator eq")
+			case (operator == "lt" || operator == "lte") && *r < *m.Threshold:
+				addf("recovery_threshold %.2f must not be below threshold %.2f for operator %s", *r, *m.Threshold, operator)
+			case operator != "lt" && operator != "lte" && *r > *m.Threshold:
+				addf("recovery_threshold %.2f must not be above threshold %.2f", *r, *m.Threshold)
+			}
+		}
+		if m.InputInterval != "" {
+			if d, err := time.ParseDuration(m.InputInterval); err != nil || d <= 0 {
+				addf("collection_interval %q must be a positive duration like 10s or 5m", m.InputInterval)
+			}
+		}
+		// Zero and negative thresholds are valid, e.g. errors > 0 or temp < -10
+		if t := *m.Threshold; isPercentField(m.Metric, m.Field) && (t < 0 || t > 100) {
+			addf("threshold %.2f must be between 0 and 100 for percentage field %s", t, m.Field)
+		}
+
+	case "http", "keyword":
+		if m.URL == "" {
+			addf("url is required")
+		} else if u, err := url.Parse(m.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			addf("url %q must be an absolute http(s) URL", m.URL)
+		}
+		if m.Timeout < 0 {
+			addf("timeout must not be negative")
+		}
+		if m.MaxRedirects != nil && *m.MaxRedirects < 0 {
+			addf("max_redirects must not be negative")
+		}
+		if m.Type == "keyword" && m.Keyword == "" {
+			addf("keyword is required")
+		}
+
+	case "tcp", "port":
+		if m.Hostname == "" {
+			addf("hostname is required")
+		}
+		if m.Port <= 0 || m.Port > 65535 {
+			addf("port must be between 1 and 65535")
+		}
+
+	case "dns":
+		if m.Hostname == "" {
+			addf("hostname is required")
+		}
+		if m.RecordType != "" && !slices.Contains(dnsRecordTypes, strings.ToUpper(m.RecordType)) {
+			addf("record_type %q is not one of %s", m.RecordType, strings.Join(dnsRecordTypes, ", "))
+		}
+		if m.Port < 0 || m.Port > 65535 {
+			addf("port must be between 1 and 65535")
+		}
+
+	case "ping":
+		if m.Hostname == "" {
+			addf("hostname is required")
+		}
+		if m.PacketSize < 0 || m.PacketSize > 65500 {
+			addf("packet_size must be between 1 and 65500")
+		}
+
+	case "mqtt":
+		if m.URL == "" {
+			addf("url is required (the broker, e.g. mqtt://broker:1883)")
+		} else if u, err := url.Parse(m.URL); err != nil || !slices.Contains(mqttSchemes, strings.ToLower(u.Scheme)) || u.Hostname() == "" {
+			addf("url must be an mqtt://, mqtts://, ws:// or wss:// broker URL")
+		} else if u.User != nil {
+			addf("url %q must not carry credentials; set username and password instead", u.Redacted())
+		}
+		if m.Topic == "" {
+			addf("topic is required")
+		}
+		if m.Password != "" && m.Username == "" {
+			addf("password requires a username")
+		}
+
+	case "raw":
+		switch m.RawType {
+		case "":
+			addf("raw_type is required (the Uptime Kuma monitor type, e.g. steam)")
+		case "push":
+			addf("raw_type push needs the agent's token handling; use push_monitors instead")
+		case "group":
+			addf("raw_type group is not a monitor; use groups instead")
+		}
+		for _, key := range slices.Sorted(maps.Keys(m.Settings)) {
+			if slices.Contains(rawBaseSettings, key) {
+				addf("settings: %s is set by the agent; use the agent's own option for it", key)
+			}
+		}
+
+	case "":
+		addf("type is required")
+
+	default:
+		addf("unsupported type")
+	}
+
+	return problems
+}
+
+// validate checks the schedule and timezone of a maintenance window.
+func (m *MaintenanceConfig) validate() []string {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if len(m.Monitors) == 0 {
+		addf("monitors must list at least one monitor or group")
+	}
+	loc, err := m.Location()
+	if err != nil {
+		addf("timezone %q is not a valid IANA time zone", m.Timezone)
+	}
+
+	switch {
+	case m.Cron != "" && (m.Start != "" || m.End != ""):
+		addf("set either cron or start/end, not both")
+	case m.Cron != "":
+		if n := len(strings.Fields(m.Cron)); n != 5 && n != 6 {
+			addf("cron %q must have 5 fields (minute hour day month weekday)", m.Cron)
+		}
+		if m.Duration < 0 {
+			addf("duration must not be negative")
+		}
+	case m.Start != "" || m.End != "":
+		if loc == nil {
+			break
+		}
+		start, startErr := time.ParseInLocation(MaintenanceTimeLayout, m.Start, loc)
+		end, endErr := time.ParseInLocation(MaintenanceTimeLayout, m.End, loc)
+		if startErr != nil || endErr != nil {
+			addf("start and end must both be set as \"YYYY-MM-DD HH:MM\" (got %q and %q)", m.Start, m.End)
+		} else if !end.After(start) {
+			addf("end %s must be after start %s", m.End, m.Start)
+		}
+	default:
+		addf("cron or start/end is required")
+	}
+
+	return problems
+}
+
+// CheckTOML reports whether snippet parses as a Telegraf TOML config fragment.
+func CheckTOML(snippet string) error {
+	var parsed map[string]any
+	_, err := toml.Decode(snippet, &parsed)
+	return err
+}
+
+// CheckExecBinary reports why path can't be run by the Telegraf exec as the agent
+// binary, or nil when it can.
+func CheckExecBinary(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("%s is not an absolute path", path) // Telegraf's working directory isn't ours
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("%s is not an executable file", path)
+	}
+	return nil
+}
+
+// isPercentField reports whether a Telegraf field of metric is a percentage.
+func isPercentField(metric, field string) bool {
+	return metric == "cpu" || strings.HasSuffix(field, "_percent")
+}
@@ -2,6 +2,7 @@ package logging
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -31,6 +32,8 @@ func (f *CustomFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 const (
 	DefaultLogLevel   = "info"
 	DefaultLogFormat  = "text"
+	DefaultLogOutput  = "file"
+	DefaultSyslogTag  = "uptime-kuma-agent"
 	DefaultLogFile    = "/var/log/uptime-kuma-agent/app.log"
 	DefaultMaxSize    = 10 // MB
 	DefaultMaxAge     = 30 // days
@@ -63,49 +66,86 @@ func InitLogger(cfg *config.LoggingConfig) error {
 	}
 
 	// Set output
-	logFile := GetLogFile(cfg)
-	if logFile != "" {
-		// Ensure log directory exists
-		logDir := filepath.Dir(logFile)
-		if err := os.MkdirAll(logDir, 0755); err != nil {
-			return fmt.Errorf("failed to create log directory %s: %w", logDir, err)
+	output := strings.ToLower(getLogOutput(cfg))
+	if output == "syslog" {
+		hook, err := newSyslogHook(cfg)
+		if err != nil {
+			return err
 		}
+		Logger.SetOutput(io.Discard) // the hook does the writing
+		Logger.AddHook(hook)
 
-		// Configure log rotation for our application logger
-		lumberjackLogger := &lumberjack.Logger{
-			Filename:   logFile,
-			MaxSize:    getMaxSize(cfg),
-			MaxAge:     getMaxAge(cfg),
-			MaxBackups: getMaxBackups(cfg),
-			Compress:   getCompress(cfg),
+		// Global logrus and the standard Go logger go to syslog as well
+		logrus.SetOutput(io.Discard)
+		logrus.AddHook(hook)
+		logrus.SetLevel(logLevel)
+		logrus.SetFormatter(Logger.Formatter)
+		log.SetOutput(hook.Writer)
+	} else {
+		var out io.Writer
+		switch output {
+		case "stdout":
+			out = os.Stdout
+		case "file", "both":
+			rotated, err := newRotatedFile(cfg)
+			if err != nil {
+				return err
+			}
+			out = rotated
+			if output == "both" {
+				out = io.MultiWriter(os.Stdout, rotated) // one formatted line, written to each sink
+			}
+		default:
+			return fmt.Errorf("invalid log output '%s' (expected file, stdout, both or syslog)", output)
 		}
-		Logger.SetOutput(lumberjackLogger)
+		Logger.SetOutput(out)
 
 		// Configure GLOBAL logrus for external libraries (Socket.IO client)
-		logrus.SetOutput(lumberjackLogger)      // Same file!
-		logrus.SetLevel(logLevel)               // Same level
-		logrus.SetFormatter(&CustomFormatter{}) // Same format
+		logrus.SetOutput(out)                 // Same sinks!
+		logrus.SetLevel(logLevel)             // Same level
+		logrus.SetFormatter(Logger.Formatter) // Same format
 
 		// Configure standard Go logger for any libraries using log package
-		log.SetOutput(lumberjackLogger) // Redirect standard log to file too
-	} else {
-		// Log to stdout/stderr
-		Logger.SetOutput(os.Stdout)
-		// Global logrus also to stdout for external libraries
-		logrus.SetOutput(os.Stdout)
-		logrus.SetLevel(logLevel)
-		logrus.SetFormatter(&CustomFormatter{})
-		// Standard Go logger also to stdout
-		log.SetOutput(os.Stdout)
+		log.SetOutput(out)
 	}
 
 	return nil
 }
 
+// newRotatedFile opens the rotated log file, falling back to stdout when no path is set.
+func newRotatedFile(cfg *config.LoggingConfig) (io.Writer, error) {
+	logFile := GetLogFile(cfg)
+	if logFile == "" {
+		return os.Stdout, nil
+	}
+
+	// Ensure log directory exists
+	logDir := filepath.Dir(logFile)
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory %s: %w", logDir, err)
+	}
+
+	// Configure log rotation for our application logger
+	return &lumberjack.Logger{
+		Filename:   logFile,
+		MaxSize:    getMaxSize(cfg),
+		MaxAge:     getMaxAge(cfg),
+		MaxBackups: getMaxBackups(cfg),
+		Compress:   getCompress(cfg),
+	}, nil
+}
+
+// LevelOverride is set from the --log-level flag and wins over env and config.
+var LevelOverride string
+
 // Precedence functions (env var > config > default)
 
-// getLogLevel returns log level with proper precedence: env var > config > default
+// getLogLevel returns log level with proper precedence: CLI flag > env var > config > default
 func getLogLevel(cfg *config.LoggingConfig) string {
+	// CLI flag
+	if LevelOverride != "" {
+		return LevelOverride
+	}
 	// Environment variable
 	if level := os.Getenv("UPTIME_KUMA_AGENT_LOG_LEVEL"); level != "" {
 		return level
@@ -131,6 +171,19 @@ func getLogFormat(cfg *config.LoggingConfig) string {
 	return DefaultLogFormat
 }
 
+// getLogOutput returns where logs go with proper precedence: env var > config > default
+func getLogOutput(cfg *config.LoggingConfig) string {
+	// Environment variable
+	if output := os.Getenv("UPTIME_KUMA_AGENT_LOG_OUTPUT"); output != "" {
+		return output
+	}
+	// Config file value
+	if cfg != nil && cfg.Output != "" {
+		return cfg.Output
+	}
+	return DefaultLogOutput
+}
+
 // GetLogFile returns log file path with proper precedence: env var > config > default
 func GetLogFile(cfg *config.LoggingConfig) string {
 	// Environment variable
@@ -246,6 +299,23 @@ func GetSocketIOLogLevel(cfg *config.LoggingConfig) string {
 	return "warn"
 }
 
+// Fields are structured key/values attached to a log entry. The JSON format emits them
+// as keys; the text format prints only the message.
+type Fields = logrus.Fields
+
+// Entry is a log entry carrying Fields.
+type Entry = logrus.Entry
+
+// WithFields returns an entry that logs with fields attached.
+func WithFields(fields Fields) *Entry {
+	if Logger == nil {
+		discard := logrus.New()
+		discard.SetOutput(io.Discard)
+		return discard.WithFields(fields)
+	}
+	return Logger.WithFields(fields)
+}
+
 // Convenience functions for logging
 func Debug(args ...interface{}) {
 	if Logger != nil {
@@ -295,14 +365,18 @@ func Errorf(format string, args ...interface{}) {
 	}
 }
 
+// Fatal logs and exits with status 1. Before InitLogger it writes to stderr, so errors
+// such as an unreadable config are never swallowed.
 func Fatal(args ...interface{}) {
 	if Logger != nil {
 		Logger.Fatal(args...)
 	}
+	log.Fatal(args...)
 }
 
 func Fatalf(format string, args ...interface{}) {
 	if Logger != nil {
 		Logger.Fatalf(format, args...)
 	}
+	log.Fatalf(format, args...)
 }
@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+)
+
+func TestGetLogLevelPrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		flag   string
+		env    string
+		config *config.LoggingConfig
+		want   string
+	}{
+		{"flag wins over all", "debug", "warn", &config.LoggingConfig{Level: "error"}, "debug"},
+		{"env wins over config", "", "warn", &config.LoggingConfig{Level: "error"}, "warn"},
+		{"config without flag or env", "", "", &config.LoggingConfig{Level: "error"}, "error"},
+		{"flag without config", "debug", "", nil, "debug"},
+		{"default for empty config", "", "", &config.LoggingConfig{}, DefaultLogLevel},
+		{"default without config", "", "", nil, DefaultLogLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("UPTIME_KUMA_AGENT_LOG_LEVEL", tt.env)
+			saved := LevelOverride
+			LevelOverride = tt.flag
+			defer func() { LevelOverride = saved }()
+
+			if got := getLogLevel(tt.config); got != tt.want {
+				t.Errorf("getLogLevel = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+	lsyslog "github.com/sirupsen/logrus/hooks/syslog"
+)
+
+// syslogFacilities maps syslog_facility names to their priority bits.
+var syslogFacilities = map[string]syslog.Priority{
+	"kern": syslog.LOG_KERN, "user": syslog.LOG_USER, "mail": syslog.LOG_MAIL,
+	"daemon": syslog.LOG_DAEMON, "auth": syslog.LOG_AUTH, "syslog": syslog.LOG_SYSLOG,
+	"lpr": syslog.LOG_LPR, "news": syslog.LOG_NEWS, "uucp": syslog.LOG_UUCP,
+	"cron": syslog.LOG_CRON, "authpriv": syslog.LOG_AUTHPRIV, "ftp": syslog.LOG_FTP,
+	"local0": syslog.LOG_LOCAL0, "local1": syslog.LOG_LOCAL1, "local2": syslog.LOG_LOCAL2,
+	"local3": syslog.LOG_LOCAL3, "local4": syslog.LOG_LOCAL4, "local5": syslog.LOG_LOCAL5,
+	"local6": syslog.LOG_LOCAL6, "local7": syslog.LOG_LOCAL7,
+}
+
+// newSyslogHook connects to the local syslog daemon, or to syslog_address over
+// syslog_network, with the configured facility and tag.
+func newSyslogHook(cfg *config.LoggingConfig) (*lsyslog.SyslogHook, error) {
+	facility, tag := "daemon", DefaultSyslogTag
+	var network, address string
+	if cfg != nil {
+		if cfg.SyslogFacility != "" {
+			facility = strings.ToLower(cfg.SyslogFacility)
+		}
+		if cfg.SyslogTag != "" {
+			tag = cfg.SyslogTag
+		}
+		network, address = cfg.SyslogNetwork, cfg.SyslogAddress
+	}
+
+	priority, ok := syslogFacilities[facility]
+	if !ok {
+		return nil, fmt.Errorf("invalid syslog facility '%s'", facility)
+	}
+	hook, err := lsyslog.NewSyslogHook(network, address, priority|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return hook, nil
+}
@@ -0,0 +1,21 @@
+//go:build windows || plan9
+
+package logging
+
+import (
+	"errors"
+	"io"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// syslogHook mirrors the fields InitLogger uses from the syslog hook.
+type syslogHook struct {
+	logrus.Hook
+	Writer io.Writer
+}
+
+func newSyslogHook(cfg *config.LoggingConfig) (*syslogHook, error) {
+	return nil, errors.New("syslog output is not supported on this platform")
+}
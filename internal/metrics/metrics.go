@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gitisz/uptime-kuma-agent/internal/provision"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// registry holds only the agent's own metrics, so a Pushgateway receives those without
+// the Go runtime metrics.
+var registry = prometheus.NewRegistry()
+
+var (
+	runsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kuma_agent_runs_total",
+		Help: "Provisioning runs by outcome (success, failed, skipped).",
+	}, []string{"status"})
+	monitorsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kuma_agent_monitors_created_total",
+		Help: "Monitors, groups and notifications created in Uptime Kuma.",
+	})
+	monitorsUpdated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kuma_agent_monitors_updated_total",
+		Help: "Existing monitors and groups updated to match the config.",
+	})
+	monitorsUnchanged = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kuma_agent_monitors_unchanged_total",
+		Help: "Existing monitors and groups that already matched the config.",
+	})
+	monitorsDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kuma_agent_monitors_deleted_total",
+		Help: "Orphaned monitors deleted by --prune.",
+	})
+	errorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kuma_agent_errors_total",
+		Help: "Errors recorded during provisioning runs.",
+	})
+	runDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kuma_agent_run_duration_seconds",
+		Help:    "Duration of provisioning runs.",
+		Buckets: []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60},
+	})
+)
+
+func init() {
+	registry.MustRegister(runsTotal, monitorsCreated, monitorsUpdated, monitorsUnchanged, monitorsDeleted, errorsTotal, runDuration)
+}
+
+// Observe adds a finished run report to the metrics.
+func Observe(report *provision.RunReport) {
+	status := report.Status
+	if report.Skipped {
+		status = "skipped"
+	}
+	runsTotal.WithLabelValues(status).Inc()
+	monitorsCreated.Add(float64(report.Created))
+	monitorsUpdated.Add(float64(report.Updated))
+	monitorsUnchanged.Add(float64(report.Unchanged))
+	monitorsDeleted.Add(float64(report.Deleted))
+	errorsTotal.Add(float64(len(report.Errors)))
+	runDuration.Observe(report.Duration)
+}
+
+// Handler serves the agent metrics together with the Go runtime and process metrics.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(prometheus.Gatherers{registry, prometheus.DefaultGatherer}, promhttp.HandlerOpts{})
+}
+
+// Push sends the agent metrics to the Pushgateway at url, replacing the previous push of
+// the same job.
+func Push(url, job string) error {
+	if err := push.New(url, job).Gatherer(registry).Push(); err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", url, err)
+	}
+	return nil
+}
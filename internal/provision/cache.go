@@ -0,0 +1,118 @@
+package provision
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	kuma "github.com/breml/go-uptime-kuma-client"
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+	"github.com/gitisz/uptime-kuma-agent/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+// RunCache records the config and Uptime Kuma state seen after the last successful run,
+// so an identical follow-up run can be skipped.
+type RunCache struct {
+	ConfigHash string    `json:"config_hash"`
+	StateHash  string    `json:"state_hash"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// LoadRunCache reads the cache at path, returning nil when no cache exists yet.
+func LoadRunCache(path string) (*RunCache, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run cache: %w", err)
+	}
+
+	var cache RunCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("failed to parse run cache %s: %w", path, err)
+	}
+	return &cache, nil
+}
+
+// Save writes the cache atomically (temp file + rename) so a crash never leaves a
+// truncated cache behind.
+func (c *RunCache) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := util.WriteFileAtomic(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write run cache: %w", err)
+	}
+	return nil
+}
+
+// Matches reports whether the cache was recorded for the same config and state hashes.
+func (c *RunCache) Matches(configHash, stateHash string) bool {
+	return c != nil && c.ConfigHash == configHash && c.StateHash == stateHash
+}
+
+// ConfigHash hashes the merged config, including values expanded from the environment,
+// together with the run options, so e.g. a --prune run after a plain one isn't skipped.
+func ConfigHash(cfg *config.Config, opts Options) (string, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash config: %w", err)
+	}
+	optData, err := json.Marshal(opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash run options: %w", err)
+	}
+	sum := sha256.Sum256(append(append(data, 0), optData...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// StateHash hashes everything a run provisions as currently known to Uptime Kuma: the
+// monitors, notifications, proxies, maintenance windows and status pages. Deleting any
+// of them in the UI then makes the next run recreate it.
+func StateHash(ctx context.Context, client *kuma.Client) (string, error) {
+	monitors, err := client.GetMonitors(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch monitors: %w", err)
+	}
+	sort.Slice(monitors, func(i, j int) bool { return monitors[i].ID < monitors[j].ID })
+
+	notifications := client.GetNotifications(ctx)
+	sort.Slice(notifications, func(i, j int) bool { return notifications[i].ID < notifications[j].ID })
+
+	proxies := client.GetProxyList(ctx)
+	sort.Slice(proxies, func(i, j int) bool { return proxies[i].ID < proxies[j].ID })
+
+	maintenances, err := client.GetMaintenances(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch maintenance windows: %w", err)
+	}
+	sort.Slice(maintenances, func(i, j int) bool { return maintenances[i].ID < maintenances[j].ID })
+
+	statusPages, err := client.GetStatusPages(ctx) // a map, which encoding/json sorts by key
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch status pages: %w", err)
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"monitors":      monitors,
+		"notifications": notifications,
+		"proxies":       proxies,
+		"maintenance":   maintenances,
+		"status_pages":  statusPages,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash Uptime Kuma state: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
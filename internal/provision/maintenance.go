@@ -0,0 +1,186 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	kuma "github.com/breml/go-uptime-kuma-client"
+	"github.com/breml/go-uptime-kuma-client/maintenance"
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+)
+
+// EnsureMaintenance creates or updates the configured maintenance windows, matched by
+// name, and sets the monitors each one covers. Windows that aren't in the config are left
+// alone. It runs after the monitors are provisioned so new monitors can be referenced; a
+// failing window is recorded and the others still run.
+func EnsureMaintenance(ctx context.Context, client *kuma.Client, cfg *config.Config, dryRun bool, report *RunReport) {
+	if len(cfg.Maintenance) == 0 {
+		return
+	}
+
+	windows, err := client.GetMaintenances(ctx)
+	if err != nil {
+		logging.Warnf("Warning: failed to get maintenance windows: %v", err)
+		report.recordError("get maintenance windows: %v", err)
+		return
+	}
+	existing := make(map[string]maintenance.Maintenance)
+	for _, w := range windows {
+		existing[w.Title] = w
+	}
+
+	monitors, err := client.GetMonitors(ctx)
+	if err != nil {
+		logging.Warnf("Warning: failed to get monitors for maintenance windows: %v", err)
+		report.recordError("get monitors for maintenance windows: %v", err)
+		return
+	}
+	monitorIDs := make(map[string][]int64) // name -> IDs, more than one when a name repeats across groups
+	for _, m := range monitors {
+		monitorIDs[m.Name] = append(monitorIDs[m.Name], m.ID)
+	}
+
+	for i := range cfg.Maintenance {
+		mcfg := &cfg.Maintenance[i]
+		if err := ensureMaintenance(ctx, client, mcfg, existing, monitorIDs, dryRun, report); err != nil {
+			monitorEvent("update", "maintenance", mcfg.Name, 0, dryRun).Warnf("Warning: failed to provision maintenance %s: %v", mcfg.Name, err)
+			report.recordError("maintenance %s: %v", mcfg.Name, err)
+		}
+	}
+}
+
+// ensureMaintenance creates or updates one maintenance window and its monitor list.
+func ensureMaintenance(ctx context.Context, client *kuma.Client, mcfg *config.MaintenanceConfig, existing map[string]maintenance.Maintenance, monitorIDs map[string][]int64, dryRun bool, report *RunReport) error {
+	want, err := buildMaintenance(mcfg)
+	if err != nil {
+		return err
+	}
+
+	var ids []int64
+	var missing []string
+	for _, name := range mcfg.Monitors {
+		if found, ok := monitorIDs[name]; ok {
+			ids = append(ids, found...)
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		if !dryRun {
+			return fmt.Errorf("monitor names not found: %s", strings.Join(missing, ", "))
+		}
+		// A real run may create them first
+		logging.Debugf("Maintenance %s: monitors not found yet: %v", mcfg.Name, missing)
+	}
+	slices.Sort(ids)
+
+	current, exists := existing[mcfg.Name]
+	if !exists {
+		if dryRun {
+			monitorEvent("create", "maintenance", mcfg.Name, 0, true).Infof("WOULD CREATE maintenance %s (%s, %d monitors)", mcfg.Name, want.Strategy, len(ids))
+			report.recordCreated("maintenance", mcfg.Name, 0)
+			return nil
+		}
+		created, err := client.CreateMaintenance(ctx, want)
+		if err != nil {
+			return fmt.Errorf("failed to create maintenance: %w", err)
+		}
+		if err := client.SetMonitorMaintenance(ctx, created.ID, ids); err != nil {
+			return fmt.Errorf("failed to set monitors of maintenance %d: %w", created.ID, err)
+		}
+		monitorEvent("create", "maintenance", mcfg.Name, created.ID, false).Infof("Created maintenance %s (ID: %d, %d monitors)", mcfg.Name, created.ID, len(ids))
+		report.recordCreated("maintenance", mcfg.Name, created.ID)
+		return nil
+	}
+
+	settingsChanged := maintenanceChanged(&current, want)
+	currentIDs, err := client.GetMonitorMaintenance(ctx, current.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get monitors of maintenance %d: %w", current.ID, err)
+	}
+	slices.Sort(currentIDs)
+	monitorsChanged := !slices.Equal(currentIDs, ids) && len(missing) == 0
+
+	if !settingsChanged && !monitorsChanged {
+		logging.Debugf("Maintenance %s is up to date", mcfg.Name)
+		report.recordUpdated("maintenance", mcfg.Name, current.ID, false)
+		return nil
+	}
+	if dryRun {
+		monitorEvent("update", "maintenance", mcfg.Name, current.ID, true).Infof("WOULD UPDATE maintenance %s", mcfg.Name)
+		report.recordUpdated("maintenance", mcfg.Name, current.ID, true)
+		return nil
+	}
+
+	if settingsChanged {
+		want.ID = current.ID
+		want.Active = current.Active // pausing a window in the UI is left alone
+		if err := client.UpdateMaintenance(ctx, want); err != nil {
+			return fmt.Errorf("failed to update maintenance %d: %w", current.ID, err)
+		}
+	}
+	if monitorsChanged {
+		if err := client.SetMonitorMaintenance(ctx, current.ID, ids); err != nil {
+			return fmt.Errorf("failed to set monitors of maintenance %d: %w", current.ID, err)
+		}
+	}
+	monitorEvent("update", "maintenance", mcfg.Name, current.ID, false).Infof("Updated maintenance %s", mcfg.Name)
+	report.recordUpdated("maintenance", mcfg.Name, current.ID, true)
+	return nil
+}
+
+// buildMaintenance returns the Uptime Kuma maintenance window for mcfg.
+func buildMaintenance(mcfg *config.MaintenanceConfig) (*maintenance.Maintenance, error) {
+	if mcfg.Cron != "" {
+		duration := mcfg.Duration
+		if duration <= 0 {
+			duration = config.DefaultMaintenanceDuration
+		}
+		return maintenance.NewCronMaintenance(mcfg.Name, mcfg.Description, mcfg.Cron, duration, mcfg.TimezoneOption()), nil
+	}
+
+	loc, err := mcfg.Location()
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", mcfg.Timezone, err)
+	}
+	start, err := time.ParseInLocation(config.MaintenanceTimeLayout, mcfg.Start, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start %q: %w", mcfg.Start, err)
+	}
+	end, err := time.ParseInLocation(config.MaintenanceTimeLayout, mcfg.End, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end %q: %w", mcfg.End, err)
+	}
+	return maintenance.NewSingleMaintenance(mcfg.Name, mcfg.Description, start, end, mcfg.TimezoneOption()), nil
+}
+
+// maintenanceChanged reports whether the schedule or description of current differs from
+// want. Only the fields of want's strategy are compared.
+func maintenanceChanged(current, want *maintenance.Maintenance) bool {
+	timezone := current.TimezoneOption
+	if timezone == "" {
+		timezone = config.MaintenanceServerTimezone
+	}
+	if current.Description != want.Description || current.Strategy != want.Strategy || timezone != want.TimezoneOption {
+		return true
+	}
+
+	switch want.Strategy {
+	case "cron":
+		return current.Cron != want.Cron || current.DurationMinutes != want.DurationMinutes
+	case "single":
+		if len(current.DateRange) != len(want.DateRange) {
+			return true
+		}
+		for i := range want.DateRange {
+			if current.DateRange[i] == nil || !current.DateRange[i].Equal(*want.DateRange[i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
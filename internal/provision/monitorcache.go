@@ -0,0 +1,54 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	kuma "github.com/breml/go-uptime-kuma-client"
+	"github.com/breml/go-uptime-kuma-client/monitor"
+)
+
+// MonitorCache holds the full state of the monitors fetched during a provisioning run,
+// so each monitor is requested from Uptime Kuma at most once. It is safe for concurrent
+// use; entries are invalidated whenever the agent changes the monitor.
+type MonitorCache struct {
+	client   *kuma.Client
+	mu       sync.Mutex
+	monitors map[int64]monitor.Base
+}
+
+// NewMonitorCache returns an empty cache reading through client.
+func NewMonitorCache(client *kuma.Client) *MonitorCache {
+	return &MonitorCache{client: client, monitors: make(map[int64]monitor.Base)}
+}
+
+// GetMonitorAs works like kuma.Client.GetMonitorAs, fetching the monitor only on first use.
+func (c *MonitorCache) GetMonitorAs(ctx context.Context, monID int64, target any) error {
+	c.mu.Lock()
+	mon, ok := c.monitors[monID]
+	c.mu.Unlock()
+
+	if !ok {
+		var err error
+		if mon, err = c.client.GetMonitor(ctx, monID); err != nil {
+			return err
+		}
+		c.mu.Lock()
+		c.monitors[monID] = mon
+		c.mu.Unlock()
+	}
+
+	// As decodes a fresh copy, so targets can be modified without touching the cache
+	if err := mon.As(target); err != nil {
+		return fmt.Errorf("get monitor %d as %T: %v", monID, target, err)
+	}
+	return nil
+}
+
+// Invalidate drops monitor monID, so the next read fetches its current state.
+func (c *MonitorCache) Invalidate(monID int64) {
+	c.mu.Lock()
+	delete(c.monitors, monID)
+	c.mu.Unlock()
+}
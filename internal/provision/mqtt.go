@@ -0,0 +1,110 @@
+package provision
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/breml/go-uptime-kuma-client/monitor"
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+)
+
+// mqttDefaultPorts are the broker ports used when the url has none.
+var mqttDefaultPorts = map[string]int64{"mqtt": 1883, "mqtts": 8883, "ws": 80, "wss": 443}
+
+// mqttBroker splits a broker URL into the hostname (with scheme), port and WebSocket
+// path Uptime Kuma stores separately. Errors show the URL with its password redacted.
+func mqttBroker(rawURL string) (string, int64, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("mqtt url is not a valid URL") // parse errors repeat the URL, password included
+	}
+	scheme := strings.ToLower(u.Scheme)
+	defaultPort, ok := mqttDefaultPorts[scheme]
+	if !ok || u.Hostname() == "" {
+		return "", 0, "", fmt.Errorf("mqtt url %q must look like mqtt://broker:1883 (mqtt, mqtts, ws or wss)", u.Redacted())
+	}
+
+	if u.User != nil {
+		return "", 0, "", fmt.Errorf("mqtt url %q must not carry credentials; set username and password instead", u.Redacted())
+	}
+
+	host := u.Hostname()
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6 literal
+	}
+	port := defaultPort
+	if p := u.Port(); p != "" {
+		if port, err = strconv.ParseInt(p, 10, 64); err != nil {
+			return "", 0, "", fmt.Errorf("mqtt url %q has an invalid port", u.Redacted())
+		}
+	}
+	wsPath := ""
+	if scheme == "ws" || scheme == "wss" {
+		wsPath = strings.TrimPrefix(u.Path, "/")
+	}
+	return scheme + "://" + host, port, wsPath, nil
+}
+
+// newMQTTDetails returns the broker settings for a new mqtt monitor, failing when the
+// broker url or topic is missing.
+func newMQTTDetails(mcfg *config.MonitorConfig) (monitor.MQTTDetails, error) {
+	if mcfg.URL == "" {
+		return monitor.MQTTDetails{}, fmt.Errorf("mqtt monitor %s missing url (the broker, e.g. mqtt://broker:1883)", mcfg.Name)
+	}
+	if mcfg.Topic == "" {
+		return monitor.MQTTDetails{}, fmt.Errorf("mqtt monitor %s missing topic", mcfg.Name)
+	}
+
+	details := monitor.MQTTDetails{MQTTCheckType: monitor.MQTTCheckTypeKeyword}
+	if _, err := reconcileMQTTDetails(&details, mcfg); err != nil {
+		return monitor.MQTTDetails{}, err
+	}
+	return details, nil
+}
+
+// reconcileMQTTDetails applies the configured broker, topic, credentials and expected
+// message to details and reports whether anything changed.
+func reconcileMQTTDetails(details *monitor.MQTTDetails, mcfg *config.MonitorConfig) (bool, error) {
+	updated := false
+
+	if mcfg.URL != "" {
+		hostname, port, wsPath, err := mqttBroker(mcfg.URL)
+		if err != nil {
+			return false, fmt.Errorf("mqtt monitor %s: %w", mcfg.Name, err)
+		}
+		if details.Hostname != hostname {
+			details.Hostname = hostname
+			updated = true
+		}
+		if details.Port == nil || *details.Port != port {
+			details.Port = &port
+			updated = true
+		}
+		updated = setOptional(&details.MQTTWebsocketPath, wsPath) || updated
+	}
+	if mcfg.Topic != "" && details.MQTTTopic != mcfg.Topic {
+		details.MQTTTopic = mcfg.Topic
+		updated = true
+	}
+
+	updated = setOptional(&details.MQTTUsername, mcfg.Username) || updated
+	updated = setOptional(&details.MQTTPassword, mcfg.Password) || updated
+	updated = setOptional(&details.MQTTSuccessMessage, mcfg.Keyword) || updated
+	return updated, nil
+}
+
+// setOptional sets an optional string field to value, treating nil like "", and reports
+// whether it changed.
+func setOptional(field **string, value string) bool {
+	current := ""
+	if *field != nil {
+		current = **field
+	}
+	if current == value {
+		return false
+	}
+	*field = &value
+	return true
+}
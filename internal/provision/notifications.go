@@ -0,0 +1,127 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	kuma "github.com/breml/go-uptime-kuma-client"
+	"github.com/breml/go-uptime-kuma-client/notification"
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+)
+
+// supportedNotificationTypes are the notification providers the kuma client knows, in
+// the spelling Uptime Kuma expects.
+var supportedNotificationTypes = []string{
+	"46elks", "alerta", "AlertNow", "AliyunSMS", "apprise", "bark", "Bitrix24", "brevo",
+	"CallMeBot", "Cellsynt", "clicksendsms", "DingDing", "discord", "EvolutionApi", "Feishu",
+	"FlashDuty", "FreeMobile", "GoAlert", "GoogleChat", "gorush", "gotify", "GrafanaOncall",
+	"gtxmessaging", "HeiiOnCall", "HomeAssistant", "Keep", "Kook", "line", "LineNotify",
+	"lunasea", "matrix", "mattermost", "NextcloudTalk", "nostr", "notifery", "ntfy",
+	"octopush", "OneBot", "OneChat", "onesender", "Opsgenie", "PagerDuty", "PagerTree",
+	"promosms", "Pumble", "pushbullet", "PushDeer", "pushover", "PushPlus", "pushy",
+	"rocket.chat", "SendGrid", "ServerChan", "serwersms", "sevenio", "signal", "SIGNL4",
+	"slack", "smsc", "SMSEagle", "SMSManager", "SMSPartner", "SMSPlanet", "smtp", "Splunk",
+	"SpugPush", "squadcast", "stackfield", "teams", "PushByTechulus", "telegram", "threema",
+	"twilio", "waha", "webhook", "WeCom", "whapi", "WPush", "YZJ", "ZohoCliq",
+}
+
+// notificationType returns the canonical spelling of typ, matched case-insensitively.
+func notificationType(typ string) (string, bool) {
+	for _, t := range supportedNotificationTypes {
+		if strings.EqualFold(t, typ) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// EnsureNotifications creates the configured notifications that don't exist yet in
+// Uptime Kuma. Existing notifications are matched by name and left untouched, so
+// re-runs are idempotent.
+func EnsureNotifications(ctx context.Context, client *kuma.Client, cfg *config.Config, dryRun bool, report *RunReport) error {
+	if len(cfg.Notifications) == 0 {
+		return nil
+	}
+
+	existing := make(map[string]bool)
+	for _, n := range client.GetNotifications(ctx) {
+		existing[n.Name] = true
+	}
+
+	for _, ncfg := range cfg.Notifications {
+		if existing[ncfg.Name] {
+			logging.Debugf("Notification %s already exists", ncfg.Name)
+			continue
+		}
+
+		typ, ok := notificationType(ncfg.Type)
+		if !ok {
+			return fmt.Errorf("notification %s: type %q is not supported", ncfg.Name, ncfg.Type)
+		}
+
+		if dryRun {
+			monitorEvent("create", "notification", ncfg.Name, 0, true).Infof("WOULD CREATE %s notification %s", typ, ncfg.Name)
+			report.recordCreated("notification", ncfg.Name, 0)
+			continue
+		}
+
+		details := notification.GenericDetails{}
+		for k, v := range ncfg.Settings {
+			details[k] = v
+		}
+		id, err := client.CreateNotification(ctx, notification.Generic{
+			Base: notification.Base{
+				Name:          ncfg.Name,
+				IsActive:      true,
+				IsDefault:     ncfg.IsDefault,
+				ApplyExisting: ncfg.ApplyExisting,
+			},
+			GenericDetails: details,
+			TypeName:       typ,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create notification %s: %w", ncfg.Name, err)
+		}
+		existing[ncfg.Name] = true
+		monitorEvent("create", "notification", ncfg.Name, id, false).Infof("Created %s notification %s (ID: %d)", typ, ncfg.Name, id)
+		report.recordCreated("notification", ncfg.Name, id)
+	}
+
+	return nil
+}
+
+// checkNotificationNames fails when a group or monitor references a notification name
+// that doesn't exist in Uptime Kuma, before anything is created or updated. In a dry run
+// the configured notifications count as existing, since a real run would create them.
+func checkNotificationNames(ctx context.Context, client *kuma.Client, cfg *config.Config, dryRun bool) error {
+	pending := make(map[string]bool)
+	if dryRun {
+		for _, ncfg := range cfg.Notifications {
+			pending[ncfg.Name] = true
+		}
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	add := func(refs []string) {
+		for _, name := range refs {
+			if !seen[name] && !pending[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	for _, gcfg := range cfg.Groups {
+		add(gcfg.NotificationNames)
+	}
+	for _, mcfg := range cfg.GetAllMonitors() {
+		add(mcfg.NotificationNames)
+	}
+
+	if _, err := ResolveNotificationIDs(ctx, client, names, true); err != nil {
+		return fmt.Errorf("strict_notifications: %w", err)
+	}
+	return nil
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
-	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	kuma "github.com/breml/go-uptime-kuma-client"
 	"github.com/breml/go-uptime-kuma-client/monitor"
@@ -15,71 +19,102 @@ import (
 	"github.com/gitisz/uptime-kuma-agent/internal/logging"
 )
 
-var invalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
-var multipleHyphens = regexp.MustCompile(`-+`) // Match one or more hyphens
-
-func GeneratePushToken() (string, error) {
-	bytes := make([]byte, 16)
+// GeneratePushToken returns a random push token of byteCount bytes, hex-encoded.
+func GeneratePushToken(byteCount int) (string, error) {
+	if byteCount < config.DefaultPushTokenBytes {
+		return "", fmt.Errorf("push tokens need at least %d bytes, got %d", config.DefaultPushTokenBytes, byteCount)
+	}
+	bytes := make([]byte, byteCount)
 	if _, err := rand.Read(bytes); err != nil {
 		return "", err
 	}
 	return hex.EncodeToString(bytes), nil
 }
 
-func SanitizeFilename(name string, delimiter string) string {
-	// 1. To lowercase
-	name = strings.ToLower(name)
-
-	// 2. Replace hyphens with delimiter (for metric names, we want underscores everywhere)
-	if delimiter == "_" {
-		name = strings.ReplaceAll(name, "-", delimiter)
-	}
-
-	// 3. Replace any remaining invalid chars with delimiter
-	name = invalidChars.ReplaceAllString(name, delimiter)
+// Token fetch retry settings for newly created push monitors
+const (
+	pushTokenFetchAttempts = 5
+	pushTokenFetchBackoff  = 250 * time.Millisecond
+)
 
-	// 4. Collapse multiple delimiters into one
-	var multipleDelimiters = regexp.MustCompile(delimiter + "+")
-	name = multipleDelimiters.ReplaceAllString(name, delimiter)
+// FetchPushToken reads the push token of a monitor, retrying with exponential backoff
+// while the server has not yet persisted it (empty token or fetch error).
+func FetchPushToken(ctx context.Context, client *kuma.Client, monID int64) (string, error) {
+	backoff := pushTokenFetchBackoff
+	var lastErr error
 
-	// 5. Truncate to max length (before final trim)
-	if len(name) > 50 {
-		name = name[:50]
-	}
+	for attempt := 1; attempt <= pushTokenFetchAttempts; attempt++ {
+		var push monitor.Push
+		if err := client.GetMonitorAs(ctx, monID, &push); err != nil {
+			lastErr = err
+		} else if push.PushDetails.PushToken != "" {
+			return push.PushDetails.PushToken, nil
+		} else {
+			lastErr = fmt.Errorf("push token empty")
+		}
 
-	// 6. Trim leading/trailing delimiters
-	name = strings.Trim(name, delimiter)
+		if attempt == pushTokenFetchAttempts {
+			break
+		}
+		logging.Debugf("Push token for monitor %d not available yet (attempt %d/%d): %v - retrying in %s",
+			monID, attempt, pushTokenFetchAttempts, lastErr, backoff)
 
-	// 7. Fallback if name became empty
-	if name == "" {
-		name = "monitor"
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
 	}
 
-	return name
+	return "", fmt.Errorf("failed to fetch push token for monitor %d after %d attempts: %w", monID, pushTokenFetchAttempts, lastErr)
 }
 
-// Backward compatibility wrapper.
-func SanitizeFilenameHyphen(name string) string {
-	return SanitizeFilename(name, "-")
+// monitorEvent returns a logger carrying the structured fields of a provisioning action
+// on a monitor or group, for queryable JSON logs. id is 0 for monitors not created yet.
+func monitorEvent(action, typ, name string, id int64, dryRun bool) *logging.Entry {
+	fields := logging.Fields{"action": action, "type": typ, "monitor": name}
+	if id != 0 {
+		fields["id"] = id
+	}
+	if dryRun {
+		fields["dry_run"] = true
+	}
+	return logging.WithFields(fields)
 }
 
-// Add this function anywhere in your file (e.g., near provisioning logic)
-func ResolveNotificationIDs(ctx context.Context, client *kuma.Client, names []string) ([]int64, error) {
+// ResolveNotificationIDs maps notification names to their Uptime Kuma IDs. Unknown names
+// are skipped with a warning, or returned as an error when strict is set.
+func ResolveNotificationIDs(ctx context.Context, client *kuma.Client, names []string, strict bool) ([]int64, error) {
 	if len(names) == 0 {
 		return nil, nil
 	}
+	return newNotificationIndex(ctx, client).resolve(names, strict)
+}
 
-	notifications := client.GetNotifications(ctx)
+// notificationIndex maps notification names to IDs. A provisioning run builds it once,
+// after creating missing notifications, instead of indexing the client's notification
+// list again for every group and monitor.
+type notificationIndex map[string]int64
 
-	nameToID := make(map[string]int64)
-	for _, n := range notifications {
-		nameToID[n.Name] = n.ID
+func newNotificationIndex(ctx context.Context, client *kuma.Client) notificationIndex {
+	idx := make(notificationIndex)
+	for _, n := range client.GetNotifications(ctx) {
+		idx[n.Name] = n.ID
+	}
+	return idx
+}
+
+// resolve works like ResolveNotificationIDs on the indexed notifications.
+func (idx notificationIndex) resolve(names []string, strict bool) ([]int64, error) {
+	if len(names) == 0 {
+		return nil, nil
 	}
 
 	var ids []int64
 	missing := make([]string, 0)
 	for _, name := range names {
-		if id, found := nameToID[name]; found {
+		if id, found := idx[name]; found {
 			ids = append(ids, id)
 		} else {
 			missing = append(missing, name)
@@ -87,92 +122,502 @@ func ResolveNotificationIDs(ctx context.Context, client *kuma.Client, names []st
 	}
 
 	if len(missing) > 0 {
+		if strict {
+			return nil, fmt.Errorf("notification names not found: %s", strings.Join(missing, ", "))
+		}
 		logging.Warnf("Warning: notification names not found: %v", missing)
 	}
 
 	return ids, nil
 }
 
-func UpdateMonitorBase(ctx context.Context, client *kuma.Client, monID int64, mcfg *config.MonitorConfig, groupNotificationIDs []int64) error {
+// reconcileBase updates the shared monitor settings the agent manages for mcfg
+// (description, notifications, interval, max retries) and reports whether anything changed.
+func reconcileBase(cfg *config.Config, base *monitor.Base, mcfg *config.MonitorConfig, notificationIDs []int64) bool {
+	updated := false
+
+	if cfg.Manages(mcfg, config.ManagedFieldDescription) {
+		if base.Description == nil || (mcfg.Description != nil && *base.Description != *mcfg.Description) {
+			base.Description = mcfg.Description
+			updated = true
+		}
+	}
+
+	if cfg.Manages(mcfg, config.ManagedFieldNotifications) && !reflect.DeepEqual(base.NotificationIDs, notificationIDs) {
+		base.NotificationIDs = notificationIDs
+		updated = true
+	}
+
+	if cfg.Manages(mcfg, config.ManagedFieldInterval) && cfg.Interval > 0 && base.Interval != int64(cfg.Interval) {
+		base.Interval = int64(cfg.Interval)
+		updated = true
+	}
+
+	if cfg.Manages(mcfg, config.ManagedFieldMaxRetries) && base.MaxRetries != int64(cfg.MaxRetries) {
+		base.MaxRetries = int64(cfg.MaxRetries)
+		updated = true
+	}
+
+	return updated
+}
+
+// UpdateMonitorBase reconciles an existing monitor with its config, reporting whether an
+// update was needed. notificationIDs are the resolved notification_names of mcfg and tagIDs
+// the tag IDs from EnsureTags. The monitor is read through monitors (nil for no cache).
+// Only the fields in the monitor's managed set are compared; with dryRun set the update
+// is only logged.
+func UpdateMonitorBase(ctx context.Context, client *kuma.Client, monitors *MonitorCache, cfg *config.Config, monID int64, mcfg *config.MonitorConfig, notificationIDs []int64, tagIDs map[string]int64, dryRun bool) (bool, error) {
+	if monitors == nil {
+		monitors = NewMonitorCache(client)
+	}
+	var mon monitor.Monitor
+	var base *monitor.Base
+	manageSettings := cfg.Manages(mcfg, config.ManagedFieldSettings)
 	updated := false
 
 	switch mcfg.Type {
 	case "push":
 		var push monitor.Push
-		if err := client.GetMonitorAs(ctx, monID, &push); err != nil {
-			return fmt.Errorf("failed to fetch push monitor %d: %w", monID, err)
+		if err := monitors.GetMonitorAs(ctx, monID, &push); err != nil {
+			return false, fmt.Errorf("failed to fetch push monitor %d: %w", monID, err)
 		}
+		mon, base = &push, &push.Base
 
-		if push.Base.Description == nil || (mcfg.Description != nil && *push.Base.Description != *mcfg.Description) {
-			push.Base.Description = mcfg.Description
-			updated = true
+	case "http":
+		var httpMon monitor.HTTP
+		if err := monitors.GetMonitorAs(ctx, monID, &httpMon); err != nil {
+			return false, fmt.Errorf("failed to fetch http monitor %d: %w", monID, err)
 		}
+		mon, base = &httpMon, &httpMon.Base
 
-		targetIDs := groupNotificationIDs
-		if len(mcfg.NotificationNames) > 0 {
-			ids, err := ResolveNotificationIDs(ctx, client, mcfg.NotificationNames)
+		if manageSettings {
+			changed, err := reconcileHTTPDetails(&httpMon.HTTPDetails, mcfg)
 			if err != nil {
-				return err
+				return false, err
+			}
+			updated = updated || changed
+			if changed, err = reconcileProxy(ctx, client, cfg, base, mcfg, dryRun); err != nil {
+				return false, err
 			}
-			targetIDs = ids
+			updated = updated || changed
 		}
 
-		if !reflect.DeepEqual(push.Base.NotificationIDs, targetIDs) {
-			push.Base.NotificationIDs = targetIDs
-			updated = true
+	case "keyword":
+		var keywordMon monitor.HTTPKeyword
+		if err := monitors.GetMonitorAs(ctx, monID, &keywordMon); err != nil {
+			return false, fmt.Errorf("failed to fetch keyword monitor %d: %w", monID, err)
+		}
+		mon, base = &keywordMon, &keywordMon.Base
+
+		if manageSettings {
+			changed, err := reconcileHTTPDetails(&keywordMon.HTTPDetails, mcfg)
+			if err != nil {
+				return false, err
+			}
+			updated = updated || changed
+			if changed, err = reconcileProxy(ctx, client, cfg, base, mcfg, dryRun); err != nil {
+				return false, err
+			}
+			updated = updated || changed
+			if mcfg.Keyword != "" && keywordMon.Keyword != mcfg.Keyword {
+				keywordMon.Keyword = mcfg.Keyword
+				updated = true
+			}
+			if keywordMon.InvertKeyword != mcfg.InvertKeyword {
+				keywordMon.InvertKeyword = mcfg.InvertKeyword
+				updated = true
+			}
+		}
+
+	case "tcp", "port":
+		var tcpMon monitor.TCPPort
+		if err := monitors.GetMonitorAs(ctx, monID, &tcpMon); err != nil {
+			return false, fmt.Errorf("failed to fetch tcp monitor %d: %w", monID, err)
 		}
+		mon, base = &tcpMon, &tcpMon.Base
 
-		if updated {
-			if err := client.UpdateMonitor(ctx, &push); err != nil {
-				return fmt.Errorf("failed to update push monitor %d: %w", monID, err)
+		if manageSettings {
+			if mcfg.Hostname != "" && tcpMon.Hostname != mcfg.Hostname {
+				tcpMon.Hostname = mcfg.Hostname
+				updated = true
+			}
+			if mcfg.Port > 0 && tcpMon.Port != mcfg.Port {
+				tcpMon.Port = mcfg.Port
+				updated = true
 			}
 		}
 
-	case "http":
-		var httpMon monitor.HTTP
-		if err := client.GetMonitorAs(ctx, monID, &httpMon); err != nil {
-			return fmt.Errorf("failed to fetch http monitor %d: %w", monID, err)
+	case "dns":
+		var dnsMon monitor.DNS
+		if err := monitors.GetMonitorAs(ctx, monID, &dnsMon); err != nil {
+			return false, fmt.Errorf("failed to fetch dns monitor %d: %w", monID, err)
 		}
+		mon, base = &dnsMon, &dnsMon.Base
 
-		if httpMon.Base.Description == nil || (mcfg.Description != nil && *httpMon.Base.Description != *mcfg.Description) {
-			httpMon.Base.Description = mcfg.Description
-			updated = true
+		if manageSettings {
+			if mcfg.Hostname != "" && dnsMon.Hostname != mcfg.Hostname {
+				dnsMon.Hostname = mcfg.Hostname
+				updated = true
+			}
+			if mcfg.ResolverServer != "" && dnsMon.ResolverServer != mcfg.ResolverServer {
+				dnsMon.ResolverServer = mcfg.ResolverServer
+				updated = true
+			}
+			if recordType := monitor.DNSResolveType(strings.ToUpper(mcfg.RecordType)); recordType != "" && dnsMon.ResolveType != recordType {
+				dnsMon.ResolveType = recordType
+				updated = true
+			}
+			if mcfg.Port > 0 && dnsMon.Port != mcfg.Port {
+				dnsMon.Port = mcfg.Port
+				updated = true
+			}
 		}
 
-		targetIDs := groupNotificationIDs
-		if len(mcfg.NotificationNames) > 0 {
-			ids, err := ResolveNotificationIDs(ctx, client, mcfg.NotificationNames)
+	case "ping":
+		var pingMon monitor.Ping
+		if err := monitors.GetMonitorAs(ctx, monID, &pingMon); err != nil {
+			return false, fmt.Errorf("failed to fetch ping monitor %d: %w", monID, err)
+		}
+		mon, base = &pingMon, &pingMon.Base
+
+		if manageSettings {
+			if mcfg.Hostname != "" && pingMon.Hostname != mcfg.Hostname {
+				pingMon.Hostname = mcfg.Hostname
+				updated = true
+			}
+			packetSize := mcfg.PacketSize
+			if packetSize <= 0 {
+				packetSize = defaultPingPacketSize
+			}
+			if pingMon.PacketSize != packetSize {
+				pingMon.PacketSize = packetSize
+				updated = true
+			}
+		}
+
+	case "mqtt":
+		var mqttMon monitor.MQTT
+		if err := monitors.GetMonitorAs(ctx, monID, &mqttMon); err != nil {
+			return false, fmt.Errorf("failed to fetch mqtt monitor %d: %w", monID, err)
+		}
+		mon, base = &mqttMon, &mqttMon.Base
+
+		if manageSettings {
+			changed, err := reconcileMQTTDetails(&mqttMon.MQTTDetails, mcfg)
 			if err != nil {
-				return err
+				return false, err
 			}
-			targetIDs = ids
+			updated = updated || changed
 		}
 
-		if !reflect.DeepEqual(httpMon.Base.NotificationIDs, targetIDs) {
-			httpMon.Base.NotificationIDs = targetIDs
-			updated = true
+	case "raw":
+		rawMon := rawMonitor{rawType: mcfg.RawType}
+		if err := monitors.GetMonitorAs(ctx, monID, &rawMon.Base); err != nil {
+			return false, fmt.Errorf("failed to fetch raw monitor %d: %w", monID, err)
+		}
+		if err := monitors.GetMonitorAs(ctx, monID, &rawMon.fields); err != nil {
+			return false, fmt.Errorf("failed to fetch raw monitor %d: %w", monID, err)
 		}
+		mon, base = &rawMon, &rawMon.Base
 
-		if updated {
-			if err := client.UpdateMonitor(ctx, &httpMon); err != nil {
-				return fmt.Errorf("failed to update http monitor %d: %w", monID, err)
+		if manageSettings {
+			changed, err := reconcileRawMonitor(&rawMon, mcfg)
+			if err != nil {
+				return false, err
 			}
+			updated = updated || changed
 		}
 
 	default:
 		logging.Warnf("Skipping update for monitor type %s (not supported yet)", mcfg.Type)
-		return nil
+		return false, nil
+	}
+
+	updated = reconcileBase(cfg, base, mcfg, notificationIDs) || updated
+
+	if updated && dryRun {
+		monitorEvent("update", mcfg.Type, mcfg.Name, monID, true).Infof("WOULD UPDATE %s monitor %s", mcfg.Type, mcfg.Name)
+	} else if updated {
+		if err := client.UpdateMonitor(ctx, mon); err != nil {
+			return false, fmt.Errorf("failed to update %s monitor %d: %w", mcfg.Type, monID, err)
+		}
+		monitors.Invalidate(monID)
+		monitorEvent("update", mcfg.Type, mcfg.Name, monID, false).Infof("Updated monitor %s (%s settings)", mcfg.Name, mcfg.Type)
+	}
+
+	// Saving a monitor doesn't change whether it runs; that takes a pause or resume.
+	// Without an active setting the monitor's current state is left alone.
+	if mcfg.Active != nil && base.IsActive != *mcfg.Active {
+		if err := setActive(ctx, client, monID, mcfg, dryRun); err != nil {
+			return false, err
+		}
+		monitors.Invalidate(monID)
+		updated = true
 	}
 
-	if updated {
-		logging.Infof("Updated monitor %s (description/notifications)", mcfg.Name)
+	// Tags are separate associations in Uptime Kuma, so they're reconciled after the
+	// monitor itself. Without a tags list the monitor's tags are left alone.
+	if mcfg.Tags != nil && cfg.Manages(mcfg, config.ManagedFieldTags) {
+		tagsUpdated, err := reconcileTags(ctx, client, monID, mcfg, tagIDs, dryRun)
+		if err != nil {
+			return false, err
+		}
+		updated = updated || tagsUpdated
 	}
 
+	return updated, nil
+}
+
+// setActive pauses or resumes monitor monID to match mcfg.IsActive; with dryRun set the
+// change is only logged.
+func setActive(ctx context.Context, client *kuma.Client, monID int64, mcfg *config.MonitorConfig, dryRun bool) error {
+	action, done, set := "resume", "Resumed", client.ResumeMonitor
+	if !mcfg.IsActive() {
+		action, done, set = "pause", "Paused", client.PauseMonitor
+	}
+
+	if dryRun {
+		monitorEvent(action, mcfg.Type, mcfg.Name, monID, true).Infof("WOULD %s %s monitor %s", strings.ToUpper(action), mcfg.Type, mcfg.Name)
+		return nil
+	}
+	if err := set(ctx, monID); err != nil {
+		return fmt.Errorf("failed to %s %s monitor %d: %w", action, mcfg.Type, monID, err)
+	}
+	monitorEvent(action, mcfg.Type, mcfg.Name, monID, false).Infof("%s monitor %s", done, mcfg.Name)
 	return nil
 }
 
-func ProvisionKumaMonitor(ctx context.Context, client *kuma.Client, cfg *config.Config) error {
+// pauseIfInactive pauses a newly created monitor configured with active: false, since
+// Uptime Kuma starts every monitor it creates. A failure is recorded but doesn't undo the
+// create; the next run retries it.
+func pauseIfInactive(ctx context.Context, client *kuma.Client, monID int64, mcfg *config.MonitorConfig, report *RunReport) {
+	if mcfg.IsActive() {
+		return
+	}
+	if err := setActive(ctx, client, monID, mcfg, false); err != nil {
+		logging.Warnf("Warning: %v", err)
+		report.recordError("pause monitor %s: %v", mcfg.Name, err)
+	}
+}
+
+// newHTTPDetails returns the request settings for a new http or keyword monitor.
+func newHTTPDetails(mcfg *config.MonitorConfig) (monitor.HTTPDetails, error) {
+	details := monitor.HTTPDetails{
+		URL:                 mcfg.URL,
+		Method:              "GET",
+		Body:                "",
+		HTTPBodyEncoding:    "text",
+		Headers:             "{}",
+		AcceptedStatusCodes: []string{"200-299"},
+		MaxRedirects:        10,
+		Timeout:             30,
+	}
+	if _, err := reconcileHTTPDetails(&details, mcfg); err != nil {
+		return monitor.HTTPDetails{}, err
+	}
+	return details, nil
+}
+
+// reconcileHTTPDetails applies the configured url, method, timeout, redirects, headers and body to details
+// and reports whether anything changed. Headers are compared as JSON objects, since
+// Uptime Kuma stores them as a JSON string.
+func reconcileHTTPDetails(details *monitor.HTTPDetails, mcfg *config.MonitorConfig) (bool, error) {
+	updated := false
+
+	if mcfg.URL != "" && details.URL != mcfg.URL {
+		details.URL = mcfg.URL
+		updated = true
+	}
+
+	method := strings.ToUpper(mcfg.Method)
+	if method == "" {
+		method = "GET"
+	}
+	if details.Method != method {
+		details.Method = method
+		updated = true
+	}
+
+	if mcfg.Timeout > 0 && details.Timeout != int64(mcfg.Timeout) {
+		details.Timeout = int64(mcfg.Timeout)
+		updated = true
+	}
+	if mcfg.MaxRedirects != nil && details.MaxRedirects != *mcfg.MaxRedirects {
+		details.MaxRedirects = *mcfg.MaxRedirects
+		updated = true
+	}
+
+	if details.Body != mcfg.Body {
+		details.Body = mcfg.Body
+		updated = true
+	}
+	encoding := "text"
+	if mcfg.Body != "" && json.Valid([]byte(mcfg.Body)) {
+		encoding = "json"
+	}
+	if details.HTTPBodyEncoding != encoding {
+		details.HTTPBodyEncoding = encoding
+		updated = true
+	}
+
+	wantHeaders := mcfg.Headers
+	if wantHeaders == nil {
+		wantHeaders = map[string]string{}
+	}
+	var currentHeaders map[string]string
+	if err := json.Unmarshal([]byte(details.Headers), &currentHeaders); err != nil || currentHeaders == nil {
+		currentHeaders = map[string]string{}
+	}
+	if !reflect.DeepEqual(currentHeaders, wantHeaders) || details.Headers == "" {
+		headers, err := json.Marshal(wantHeaders)
+		if err != nil {
+			return false, fmt.Errorf("failed to encode headers for monitor %s: %w", mcfg.Name, err)
+		}
+		details.Headers = string(headers)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// defaultPingPacketSize matches the Uptime Kuma UI default for ping monitors.
+const defaultPingPacketSize = 56
+
+// buildCheckMonitor builds the Uptime Kuma monitor for a non-push monitor config,
+// validating the fields its type requires.
+func buildCheckMonitor(mcfg *config.MonitorConfig, base monitor.Base) (monitor.Monitor, error) {
+	switch mcfg.Type {
+	case "http":
+		if mcfg.URL == "" {
+			return nil, fmt.Errorf("http monitor %s missing url", mcfg.Name)
+		}
+		details, err := newHTTPDetails(mcfg)
+		if err != nil {
+			return nil, err
+		}
+		return &monitor.HTTP{
+			Base:        base,
+			HTTPDetails: details,
+		}, nil
+
+	case "keyword":
+		if mcfg.URL == "" {
+			return nil, fmt.Errorf("keyword monitor %s missing url", mcfg.Name)
+		}
+		if mcfg.Keyword == "" {
+			return nil, fmt.Errorf("keyword monitor %s missing keyword", mcfg.Name)
+		}
+		details, err := newHTTPDetails(mcfg)
+		if err != nil {
+			return nil, err
+		}
+		return &monitor.HTTPKeyword{
+			Base:        base,
+			HTTPDetails: details,
+			HTTPKeywordDetails: monitor.HTTPKeywordDetails{
+				Keyword:       mcfg.Keyword,
+				InvertKeyword: mcfg.InvertKeyword,
+			},
+		}, nil
+
+	case "tcp", "port":
+		if mcfg.Hostname == "" {
+			return nil, fmt.Errorf("tcp monitor %s missing hostname", mcfg.Name)
+		}
+		if mcfg.Port <= 0 {
+			return nil, fmt.Errorf("tcp monitor %s missing port", mcfg.Name)
+		}
+		return &monitor.TCPPort{
+			Base: base,
+			TCPPortDetails: monitor.TCPPortDetails{
+				Hostname: mcfg.Hostname,
+				Port:     mcfg.Port,
+			},
+		}, nil
+
+	case "dns":
+		if mcfg.Hostname == "" {
+			return nil, fmt.Errorf("dns monitor %s missing hostname (the name to resolve)", mcfg.Name)
+		}
+		resolver := mcfg.ResolverServer
+		if resolver == "" {
+			resolver = "1.1.1.1"
+		}
+		recordType := strings.ToUpper(mcfg.RecordType)
+		if recordType == "" {
+			recordType = string(monitor.DNSResolveTypeA)
+		}
+		port := mcfg.Port
+		if port <= 0 {
+			port = 53
+		}
+		return &monitor.DNS{
+			Base: base,
+			DNSDetails: monitor.DNSDetails{
+				Hostname:       mcfg.Hostname,
+				ResolverServer: resolver,
+				ResolveType:    monitor.DNSResolveType(recordType),
+				Port:           port,
+			},
+		}, nil
+
+	case "ping":
+		if mcfg.Hostname == "" {
+			return nil, fmt.Errorf("ping monitor %s missing hostname", mcfg.Name)
+		}
+		packetSize := mcfg.PacketSize
+		if packetSize <= 0 {
+			packetSize = defaultPingPacketSize
+		}
+		return &monitor.Ping{
+			Base: base,
+			PingDetails: monitor.PingDetails{
+				Hostname:   mcfg.Hostname,
+				PacketSize: packetSize,
+			},
+		}, nil
+
+	case "mqtt":
+		details, err := newMQTTDetails(mcfg)
+		if err != nil {
+			return nil, err
+		}
+		return &monitor.MQTT{
+			Base:        base,
+			MQTTDetails: details,
+		}, nil
+
+	case "raw":
+		rawMon, err := newRawMonitor(mcfg, base)
+		if err != nil {
+			return nil, err
+		}
+		return rawMon, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported monitor type %q for monitor %s", mcfg.Type, mcfg.Name)
+	}
+}
+
+// Options controls optional provisioning behavior.
+type Options struct {
+	// Prune deletes monitors under a provisioned group that are no longer in the config.
+	Prune bool
+	// DryRun logs the planned creates, updates and deletes without applying them.
+	DryRun bool
+	// StrictNotifications fails the run when a referenced notification name doesn't exist.
+	StrictNotifications bool
+	// Concurrency is how many monitors are created or updated at a time (default 1).
+	Concurrency int
+}
+
+// ProvisionKumaMonitor creates or updates all configured groups and monitors, recording
+// the outcome of each in report.
+func ProvisionKumaMonitor(ctx context.Context, client *kuma.Client, cfg *config.Config, opts Options, report *RunReport) error {
 	logging.Info("Starting provisioning...")
+	if opts.DryRun {
+		logging.Info("DRY RUN: no changes will be applied to Uptime Kuma")
+	}
 
 	monitors, err := client.GetMonitors(ctx)
 	if err != nil {
@@ -197,13 +642,34 @@ func ProvisionKumaMonitor(ctx context.Context, client *kuma.Client, cfg *config.
 	}
 	logging.Infof("Found %d existing monitors", len(existingByName))
 
+	// Create missing notifications before anything resolves notification names
+	if err := EnsureNotifications(ctx, client, cfg, opts.DryRun, report); err != nil {
+		return err
+	}
+	// Create missing proxies before monitors reference them
+	if err := EnsureProxies(ctx, client, cfg, opts.DryRun, report); err != nil {
+		return err
+	}
+	// Create missing tags up front; the monitor tasks below only look them up
+	tagIDs, err := EnsureTags(ctx, client, cfg, opts.DryRun)
+	if err != nil {
+		return err
+	}
+	if opts.StrictNotifications || cfg.StrictNotifications() {
+		if err := checkNotificationNames(ctx, client, cfg, opts.DryRun); err != nil {
+			return err
+		}
+	}
+	notifications := newNotificationIndex(ctx, client)
+	monitorCache := NewMonitorCache(client)
+
 	// Create/update all groups and build groupName -> ID map
 	groupNameToID := make(map[string]int64)
 	for _, gcfg := range cfg.Groups {
 		// Resolve group notification IDs
 		groupNotificationIDs := []int64{}
 		if len(gcfg.NotificationNames) > 0 {
-			ids, err := ResolveNotificationIDs(ctx, client, gcfg.NotificationNames)
+			ids, err := notifications.resolve(gcfg.NotificationNames, false)
 			if err != nil {
 				return fmt.Errorf("resolve notifications for group %s: %w", gcfg.Name, err)
 			}
@@ -214,30 +680,42 @@ func ProvisionKumaMonitor(ctx context.Context, client *kuma.Client, cfg *config.
 		if groupMon, exists := existingByName[gcfg.Name]; exists {
 			groupID := groupMon.GetID()
 			groupNameToID[gcfg.Name] = groupID
-			logging.Infof("Group exists: %s (ID: %d)", gcfg.Name, groupID)
+			monitorEvent("exists", "group", gcfg.Name, groupID, false).Infof("Group exists: %s (ID: %d)", gcfg.Name, groupID)
 
 			// Update existing group
 			var currentGroup monitor.Group
-			if err := client.GetMonitorAs(ctx, groupID, &currentGroup); err == nil {
+			if err := monitorCache.GetMonitorAs(ctx, groupID, &currentGroup); err == nil {
 				updated := false
-				if (currentGroup.Base.Description == nil && gcfg.Description != nil) ||
+				if cfg.Manages(nil, config.ManagedFieldDescription) && ((currentGroup.Base.Description == nil && gcfg.Description != nil) ||
 					(currentGroup.Base.Description != nil && gcfg.Description != nil && *currentGroup.Base.Description != *gcfg.Description) ||
-					(currentGroup.Base.Description != nil && gcfg.Description == nil) {
+					(currentGroup.Base.Description != nil && gcfg.Description == nil)) {
 					currentGroup.Base.Description = gcfg.Description
 					updated = true
 				}
-				if !reflect.DeepEqual(currentGroup.Base.NotificationIDs, groupNotificationIDs) {
+				if cfg.Manages(nil, config.ManagedFieldNotifications) && !reflect.DeepEqual(currentGroup.Base.NotificationIDs, groupNotificationIDs) {
 					currentGroup.Base.NotificationIDs = groupNotificationIDs
 					updated = true
 				}
-				if updated {
+				if updated && opts.DryRun {
+					monitorEvent("update", "group", gcfg.Name, groupID, true).Infof("WOULD UPDATE group %s", gcfg.Name)
+					report.recordUpdated("group", gcfg.Name, groupID, true)
+				} else if updated {
 					if err := client.UpdateMonitor(ctx, &currentGroup); err != nil {
-						logging.Warnf("Warning: failed to update group %s: %v", gcfg.Name, err)
+						monitorEvent("update", "group", gcfg.Name, groupID, false).Warnf("Warning: failed to update group %s: %v", gcfg.Name, err)
+						report.recordError("update group %s: %v", gcfg.Name, err)
 					} else {
-						logging.Infof("Updated group %s", gcfg.Name)
+						monitorEvent("update", "group", gcfg.Name, groupID, false).Infof("Updated group %s", gcfg.Name)
+						report.recordUpdated("group", gcfg.Name, groupID, true)
 					}
+				} else {
+					report.recordUpdated("group", gcfg.Name, groupID, false)
 				}
 			}
+		} else if opts.DryRun {
+			// Use a placeholder ID so child monitors resolve the group but match nothing existing
+			groupNameToID[gcfg.Name] = -int64(len(groupNameToID) + 1)
+			monitorEvent("create", "group", gcfg.Name, 0, true).Infof("WOULD CREATE group %s", gcfg.Name)
+			report.recordCreated("group", gcfg.Name, 0)
 		} else {
 			// Create new group
 			group := &monitor.Group{
@@ -255,385 +733,541 @@ func ProvisionKumaMonitor(ctx context.Context, client *kuma.Client, cfg *config.
 				return fmt.Errorf("create group %s: %w", gcfg.Name, err)
 			}
 			groupNameToID[gcfg.Name] = id
-			logging.Infof("Created group: %s (ID: %d)", gcfg.Name, id)
+			monitorEvent("create", "group", gcfg.Name, id, false).Infof("Created group: %s (ID: %d)", gcfg.Name, id)
+			report.recordCreated("group", gcfg.Name, id)
 		}
 	}
 
-	// Track if config was updated with new tokens
-	configUpdated := false
+	// Monitors are provisioned by up to opts.Concurrency tasks at a time once the groups
+	// exist. Each task sets the token on its own monitor config; tokensUpdated tracks
+	// whether any token changed.
+	var tasks []func(report *RunReport) error
+	var tokensUpdated atomic.Bool
 
-	// Process push monitors first to update tokens
+	// Process push monitors (fetching their tokens)
 	for i := range cfg.PushMonitors {
 		mcfg := &cfg.PushMonitors[i]
-		mcfg.Type = "push" // Ensure type is set
-		mcfg.ResolveMetrics(cfg)
-
-		// Check if this monitor exists
-		var existing monitor.Base
-		var exists bool
-
-		if mcfg.Group != "" {
-			// Monitor has a group - lookup by name + group ID
-			if groupID, groupExists := groupNameToID[mcfg.Group]; groupExists {
-				groupKey := fmt.Sprintf("%s|%d", mcfg.Name, groupID)
-				existing, exists = existingByNameAndGroup[groupKey]
-				if exists {
-					logging.Infof("Grouped push monitor exists: %s (group: %s, ID: %d)", mcfg.Name, mcfg.Group, existing.GetID())
+		tasks = append(tasks, func(report *RunReport) error {
+			mcfg.Type = "push" // Ensure type is set
+			mcfg.ResolveMetrics(cfg)
+
+			// Check if this monitor exists
+			var existing monitor.Base
+			var exists bool
+
+			if mcfg.Group != "" {
+				// Monitor has a group - lookup by name + group ID
+				if groupID, groupExists := groupNameToID[mcfg.Group]; groupExists {
+					groupKey := fmt.Sprintf("%s|%d", mcfg.Name, groupID)
+					existing, exists = existingByNameAndGroup[groupKey]
+					if exists {
+						monitorEvent("exists", "push", mcfg.Name, existing.GetID(), false).Infof("Grouped push monitor exists: %s (group: %s, ID: %d)", mcfg.Name, mcfg.Group, existing.GetID())
+					}
+				} else {
+					logging.Warnf("Push monitor %s specifies unknown group %q - treating as ungrouped", mcfg.Name, mcfg.Group)
+					// Fall back to name-only lookup for unknown groups
+					existing, exists = existingByName[mcfg.Name]
 				}
 			} else {
-				logging.Warnf("Push monitor %s specifies unknown group %q - treating as ungrouped", mcfg.Name, mcfg.Group)
-				// Fall back to name-only lookup for unknown groups
+				// Monitor has no group - lookup by name only (can be overwritten)
 				existing, exists = existingByName[mcfg.Name]
+				if exists {
+					monitorEvent("exists", "push", mcfg.Name, existing.GetID(), false).Infof("Ungrouped push monitor exists: %s (ID: %d) - will be updated/overwritten", mcfg.Name, existing.GetID())
+				}
 			}
-		} else {
-			// Monitor has no group - lookup by name only (can be overwritten)
-			existing, exists = existingByName[mcfg.Name]
+
 			if exists {
-				logging.Infof("Ungrouped push monitor exists: %s (ID: %d) - will be updated/overwritten", mcfg.Name, existing.GetID())
-			}
-		}
+				// Fetch the push token for existing monitors
+				var push monitor.Push
+				if err := monitorCache.GetMonitorAs(ctx, existing.GetID(), &push); err == nil {
+					if push.PushDetails.PushToken != "" && mcfg.PushToken != push.PushDetails.PushToken {
+						mcfg.PushToken = push.PushDetails.PushToken
+						tokensUpdated.Store(true)
+						logging.Infof("Fetched and updated push token for existing monitor %s", mcfg.Name)
+					}
+				} else {
+					logging.Errorf("Failed to fetch token for existing monitor %s: %v", mcfg.Name, err)
+					report.recordError("fetch token for %s: %v", mcfg.Name, err)
+				}
 
-		if exists {
-			// Fetch the push token for existing monitors
-			var push monitor.Push
-			if err := client.GetMonitorAs(ctx, existing.GetID(), &push); err == nil {
-				if push.PushDetails.PushToken != "" && mcfg.PushToken != push.PushDetails.PushToken {
-					mcfg.PushToken = push.PushDetails.PushToken
-					configUpdated = true
-					logging.Infof("Fetched and updated push token for existing monitor %s", mcfg.Name)
+				// Resolve target notifications
+				targetIDs := []int64{}
+				if len(mcfg.NotificationNames) > 0 {
+					ids, err := notifications.resolve(mcfg.NotificationNames, false)
+					if err != nil {
+						logging.Warnf("Warning: failed to resolve notifications for %s: %v", mcfg.Name, err)
+					} else {
+						targetIDs = ids
+					}
 				}
-			} else {
-				logging.Errorf("Failed to fetch token for existing monitor %s: %v", mcfg.Name, err)
+
+				// Update description + notifications
+				updated, err := UpdateMonitorBase(ctx, client, monitorCache, cfg, existing.GetID(), mcfg, targetIDs, tagIDs, opts.DryRun)
+				if err != nil {
+					monitorEvent("update", "push", mcfg.Name, existing.GetID(), false).Warnf("Warning: failed to update monitor %s: %v", mcfg.Name, err)
+					report.recordError("update monitor %s: %v", mcfg.Name, err)
+				} else {
+					report.recordUpdated(mcfg.Type, mcfg.Name, existing.GetID(), updated)
+				}
+
+				return nil // skip creation
 			}
 
-			// Resolve target notifications
-			targetIDs := []int64{}
+			// Create new push monitor
+			notificationIDs := []int64{}
 			if len(mcfg.NotificationNames) > 0 {
-				ids, err := ResolveNotificationIDs(ctx, client, mcfg.NotificationNames)
+				ids, err := notifications.resolve(mcfg.NotificationNames, false)
 				if err != nil {
-					logging.Warnf("Warning: failed to resolve notifications for %s: %v", mcfg.Name, err)
-				} else {
-					targetIDs = ids
+					return err
 				}
+				notificationIDs = ids
 			}
 
-			// Update description + notifications
-			if err := UpdateMonitorBase(ctx, client, existing.GetID(), mcfg, targetIDs); err != nil {
-				logging.Warnf("Warning: failed to update monitor %s: %v", mcfg.Name, err)
+			// Determine parent group ID
+			var parent *int64
+			if mcfg.Group != "" {
+				if groupID, exists := groupNameToID[mcfg.Group]; exists {
+					parent = &groupID
+				} else {
+					logging.Warnf("Push monitor %s specifies unknown group %q", mcfg.Name, mcfg.Group)
+				}
+			} else if len(cfg.Groups) > 0 {
+				// Default to first group if no group specified
+				if groupID, exists := groupNameToID[cfg.Groups[0].Name]; exists {
+					parent = &groupID
+					logging.Debugf("Push monitor %s defaults to first group %q (ID: %d)", mcfg.Name, cfg.Groups[0].Name, *parent)
+				}
 			}
 
-			continue // skip creation
-		}
+			if opts.DryRun {
+				monitorEvent("create", "push", mcfg.Name, 0, true).Infof("WOULD CREATE push monitor %s (metric: %s, field: %s)", mcfg.Name, mcfg.Metric, mcfg.Field)
+				report.recordCreated("push", mcfg.Name, 0)
+				return nil
+			}
 
-		// Create new push monitor
-		notificationIDs := []int64{}
-		if len(mcfg.NotificationNames) > 0 {
-			ids, err := ResolveNotificationIDs(ctx, client, mcfg.NotificationNames)
+			// Generate unique token
+			customToken, err := GeneratePushToken(cfg.PushTokenBytes())
 			if err != nil {
-				return err
+				return fmt.Errorf("failed to generate push token: %w", err)
 			}
-			notificationIDs = ids
-		}
+			logging.Debugf("Generated custom push token for '%s': %s", mcfg.Name, customToken)
 
-		// Determine parent group ID
-		var parent *int64
-		if mcfg.Group != "" {
-			if groupID, exists := groupNameToID[mcfg.Group]; exists {
-				parent = &groupID
-			} else {
-				logging.Warnf("Push monitor %s specifies unknown group %q", mcfg.Name, mcfg.Group)
-			}
-		} else if len(cfg.Groups) > 0 {
-			// Default to first group if no group specified
-			if groupID, exists := groupNameToID[cfg.Groups[0].Name]; exists {
-				parent = &groupID
-				logging.Debugf("Push monitor %s defaults to first group %q (ID: %d)", mcfg.Name, cfg.Groups[0].Name, *parent)
+			pushMon := &monitor.Push{
+				Base: monitor.Base{
+					Name:            mcfg.Name,
+					Description:     mcfg.Description,
+					NotificationIDs: notificationIDs,
+					Interval:        int64(cfg.Interval),
+					MaxRetries:      int64(cfg.MaxRetries),
+					IsActive:        mcfg.IsActive(),
+					Parent:          parent,
+				},
+				PushDetails: monitor.PushDetails{
+					PushToken: customToken,
+				},
 			}
-		}
-
-		// Generate unique token
-		customToken, err := GeneratePushToken()
-		if err != nil {
-			return fmt.Errorf("failed to generate push token: %w", err)
-		}
-		logging.Debugf("Generated custom push token for '%s': %s", mcfg.Name, customToken)
-
-		pushMon := &monitor.Push{
-			Base: monitor.Base{
-				Name:            mcfg.Name,
-				Description:     mcfg.Description,
-				NotificationIDs: notificationIDs,
-				Interval:        int64(cfg.Interval),
-				MaxRetries:      int64(cfg.MaxRetries),
-				IsActive:        true,
-				Parent:          parent,
-			},
-			PushDetails: monitor.PushDetails{
-				PushToken: customToken,
-			},
-		}
 
-		id, err := client.CreateMonitor(ctx, pushMon)
-		if err != nil {
-			return fmt.Errorf("create push monitor %s: %w", mcfg.Name, err)
-		}
+			id, err := client.CreateMonitor(ctx, pushMon)
+			if err != nil {
+				return fmt.Errorf("create push monitor %s: %w", mcfg.Name, err)
+			}
 
-		// Fetch the actual token from the created monitor
-		if err := client.GetMonitorAs(ctx, id, &pushMon); err == nil {
-			if pushMon.PushDetails.PushToken != "" {
-				mcfg.PushToken = pushMon.PushDetails.PushToken
-				configUpdated = true
+			// Fetch the actual token from the created monitor
+			if token, err := FetchPushToken(ctx, client, id); err == nil {
+				mcfg.PushToken = token
+				tokensUpdated.Store(true)
 				logging.Debugf("Fetched push token for new monitor %s: %s", mcfg.Name, mcfg.PushToken)
 			} else {
-				logging.Warnf("New push monitor %s created but token empty", mcfg.Name)
+				logging.Errorf("Failed to fetch token for new monitor %s: %v", mcfg.Name, err)
+				report.recordError("fetch token for %s: %v", mcfg.Name, err)
 			}
-		} else {
-			logging.Errorf("Failed to fetch token for new monitor %s: %v", mcfg.Name, err)
-		}
 
-		logging.Infof("Created push monitor: %s (ID: %d)", mcfg.Name, id)
+			monitorEvent("create", "push", mcfg.Name, id, false).Infof("Created push monitor: %s (ID: %d)", mcfg.Name, id)
+			pauseIfInactive(ctx, client, id, mcfg, report)
+			attachTags(ctx, client, id, mcfg, tagIDs, report)
+			report.recordCreated("push", mcfg.Name, id)
+			return nil
+		})
 	}
 
-	// Process HTTP monitors
-	for i := range cfg.HTTPMonitors {
-		mcfg := &cfg.HTTPMonitors[i]
-		mcfg.Type = "http" // Ensure type is set
-		mcfg.ResolveMetrics(cfg)
+	// Process check monitors (HTTP, Keyword, TCP, DNS, Ping, MQTT, raw) - everything Uptime Kuma actively probes
+	checkSections := []struct {
+		label    string
+		typ      string
+		monitors []config.MonitorConfig
+	}{
+		{label: "HTTP", typ: "http", monitors: cfg.HTTPMonitors},
+		{label: "Keyword", typ: "keyword", monitors: cfg.KeywordMonitors},
+		{label: "TCP", typ: "tcp", monitors: cfg.TCPMonitors},
+		{label: "DNS", typ: "dns", monitors: cfg.DNSMonitors},
+		{label: "Ping", typ: "ping", monitors: cfg.PingMonitors},
+		{label: "MQTT", typ: "mqtt", monitors: cfg.MQTTMonitors},
+		{label: "Raw", typ: "raw", monitors: cfg.RawMonitors},
+	}
 
-		// Check if this monitor exists
-		var existing monitor.Base
-		var exists bool
+	for _, section := range checkSections {
+		for i := range section.monitors {
+			mcfg := &section.monitors[i]
+			tasks = append(tasks, func(report *RunReport) error {
+				mcfg.Type = section.typ // Ensure type is set
+				mcfg.ResolveMetrics(cfg)
+
+				// Check if this monitor exists
+				var existing monitor.Base
+				var exists bool
+
+				if mcfg.Group != "" {
+					// Monitor has a group - lookup by name + group ID
+					if groupID, groupExists := groupNameToID[mcfg.Group]; groupExists {
+						groupKey := fmt.Sprintf("%s|%d", mcfg.Name, groupID)
+						existing, exists = existingByNameAndGroup[groupKey]
+						if exists {
+							monitorEvent("exists", mcfg.Type, mcfg.Name, existing.GetID(), false).Infof("Grouped %s monitor exists: %s (group: %s, ID: %d)", section.label, mcfg.Name, mcfg.Group, existing.GetID())
+						}
+					} else {
+						logging.Warnf("%s monitor %s specifies unknown group %q - treating as ungrouped", section.label, mcfg.Name, mcfg.Group)
+						// Fall back to name-only lookup for unknown groups
+						existing, exists = existingByName[mcfg.Name]
+					}
+				} else {
+					// Monitor has no group - lookup by name only (can be overwritten)
+					existing, exists = existingByName[mcfg.Name]
+					if exists {
+						monitorEvent("exists", mcfg.Type, mcfg.Name, existing.GetID(), false).Infof("Ungrouped %s monitor exists: %s (ID: %d) - will be updated/overwritten", section.label, mcfg.Name, existing.GetID())
+					}
+				}
 
-		if mcfg.Group != "" {
-			// Monitor has a group - lookup by name + group ID
-			if groupID, groupExists := groupNameToID[mcfg.Group]; groupExists {
-				groupKey := fmt.Sprintf("%s|%d", mcfg.Name, groupID)
-				existing, exists = existingByNameAndGroup[groupKey]
 				if exists {
-					logging.Infof("Grouped HTTP monitor exists: %s (group: %s, ID: %d)", mcfg.Name, mcfg.Group, existing.GetID())
+					// Resolve target notifications
+					targetIDs := []int64{}
+					if len(mcfg.NotificationNames) > 0 {
+						ids, err := notifications.resolve(mcfg.NotificationNames, false)
+						if err != nil {
+							logging.Warnf("Warning: failed to resolve notifications for %s: %v", mcfg.Name, err)
+						} else {
+							targetIDs = ids
+						}
+					}
+
+					// Update description + notifications + type-specific settings
+					updated, err := UpdateMonitorBase(ctx, client, monitorCache, cfg, existing.GetID(), mcfg, targetIDs, tagIDs, opts.DryRun)
+					if err != nil {
+						monitorEvent("update", mcfg.Type, mcfg.Name, existing.GetID(), false).Warnf("Warning: failed to update monitor %s: %v", mcfg.Name, err)
+						report.recordError("update monitor %s: %v", mcfg.Name, err)
+					} else {
+						report.recordUpdated(mcfg.Type, mcfg.Name, existing.GetID(), updated)
+					}
+
+					return nil // skip creation
 				}
-			} else {
-				logging.Warnf("HTTP monitor %s specifies unknown group %q - treating as ungrouped", mcfg.Name, mcfg.Group)
-				// Fall back to name-only lookup for unknown groups
-				existing, exists = existingByName[mcfg.Name]
-			}
-		} else {
-			// Monitor has no group - lookup by name only (can be overwritten)
-			existing, exists = existingByName[mcfg.Name]
-			if exists {
-				logging.Infof("Ungrouped HTTP monitor exists: %s (ID: %d) - will be updated/overwritten", mcfg.Name, existing.GetID())
-			}
-		}
 
-		if exists {
-			// Resolve target notifications
-			targetIDs := []int64{}
-			if len(mcfg.NotificationNames) > 0 {
-				ids, err := ResolveNotificationIDs(ctx, client, mcfg.NotificationNames)
-				if err != nil {
-					logging.Warnf("Warning: failed to resolve notifications for %s: %v", mcfg.Name, err)
-				} else {
-					targetIDs = ids
+				notificationIDs := []int64{}
+				if len(mcfg.NotificationNames) > 0 {
+					ids, err := notifications.resolve(mcfg.NotificationNames, false)
+					if err != nil {
+						return err
+					}
+					notificationIDs = ids
 				}
-			}
 
-			// Update description + notifications
-			if err := UpdateMonitorBase(ctx, client, existing.GetID(), mcfg, targetIDs); err != nil {
-				logging.Warnf("Warning: failed to update monitor %s: %v", mcfg.Name, err)
-			}
+				// Determine parent group ID
+				var parent *int64
+				if mcfg.Group != "" {
+					if groupID, exists := groupNameToID[mcfg.Group]; exists {
+						parent = &groupID
+					} else {
+						logging.Warnf("%s monitor %s specifies unknown group %q", section.label, mcfg.Name, mcfg.Group)
+					}
+				} else if len(cfg.Groups) > 0 {
+					// Default to first group if no group specified
+					if groupID, exists := groupNameToID[cfg.Groups[0].Name]; exists {
+						parent = &groupID
+						logging.Debugf("%s monitor %s defaults to first group %q (ID: %d)", section.label, mcfg.Name, cfg.Groups[0].Name, *parent)
+					}
+				}
 
-			continue // skip creation
-		}
+				base := monitor.Base{
+					Name:            mcfg.Name,
+					Description:     mcfg.Description,
+					NotificationIDs: notificationIDs,
+					Interval:        int64(cfg.Interval),
+					MaxRetries:      int64(cfg.MaxRetries),
+					IsActive:        mcfg.IsActive(),
+					Parent:          parent,
+				}
 
-		// Create new HTTP monitor
-		if mcfg.URL == "" {
-			return fmt.Errorf("http monitor %s missing url", mcfg.Name)
-		}
+				proxyID, err := ResolveProxyID(ctx, client, cfg, mcfg.Proxy, opts.DryRun)
+				if err != nil {
+					return err
+				}
+				base.ProxyID = proxyID
 
-		notificationIDs := []int64{}
-		if len(mcfg.NotificationNames) > 0 {
-			ids, err := ResolveNotificationIDs(ctx, client, mcfg.NotificationNames)
-			if err != nil {
-				return err
-			}
-			notificationIDs = ids
-		}
+				// Create new check monitor
+				mon, err := buildCheckMonitor(mcfg, base)
+				if err != nil {
+					return err
+				}
 
-		// Determine parent group ID
-		var parent *int64
-		if mcfg.Group != "" {
-			if groupID, exists := groupNameToID[mcfg.Group]; exists {
-				parent = &groupID
-			} else {
-				logging.Warnf("HTTP monitor %s specifies unknown group %q", mcfg.Name, mcfg.Group)
-			}
-		} else if len(cfg.Groups) > 0 {
-			// Default to first group if no group specified
-			if groupID, exists := groupNameToID[cfg.Groups[0].Name]; exists {
-				parent = &groupID
-				logging.Debugf("HTTP monitor %s defaults to first group %q (ID: %d)", mcfg.Name, cfg.Groups[0].Name, *parent)
-			}
-		}
+				if opts.DryRun {
+					monitorEvent("create", mcfg.Type, mcfg.Name, 0, true).Infof("WOULD CREATE %s monitor %s", mcfg.Type, mcfg.Name)
+					report.recordCreated(mcfg.Type, mcfg.Name, 0)
+					return nil
+				}
 
-		httpMon := &monitor.HTTP{
-			Base: monitor.Base{
-				Name:            mcfg.Name,
-				Description:     mcfg.Description,
-				NotificationIDs: notificationIDs,
-				Interval:        int64(cfg.Interval),
-				MaxRetries:      int64(cfg.MaxRetries),
-				IsActive:        true,
-				Parent:          parent,
-			},
-			HTTPDetails: monitor.HTTPDetails{
-				URL:                 mcfg.URL,
-				Method:              "GET",
-				Body:                "",
-				HTTPBodyEncoding:    "text",
-				Headers:             "{}",
-				AcceptedStatusCodes: []string{"200-299"},
-				MaxRedirects:        10,
-				Timeout:             30,
-			},
-		}
+				id, err := client.CreateMonitor(ctx, mon)
+				if err != nil {
+					return fmt.Errorf("create %s monitor %s: %w", mcfg.Type, mcfg.Name, err)
+				}
 
-		id, err := client.CreateMonitor(ctx, httpMon)
-		if err != nil {
-			return fmt.Errorf("create http monitor %s: %w", mcfg.Name, err)
+				monitorEvent("create", mcfg.Type, mcfg.Name, id, false).Infof("Created %s monitor: %s (ID: %d)", section.label, mcfg.Name, id)
+				pauseIfInactive(ctx, client, id, mcfg, report)
+				attachTags(ctx, client, id, mcfg, tagIDs, report)
+				report.recordCreated(mcfg.Type, mcfg.Name, id)
+				return nil
+			})
 		}
-
-		logging.Infof("Created HTTP monitor: %s (ID: %d)", mcfg.Name, id)
 	}
 
 	// Process legacy monitors (for backward compatibility)
 	for i := range cfg.Monitors {
 		mcfg := &cfg.Monitors[i]
-		mcfg.ResolveMetrics(cfg)
+		tasks = append(tasks, func(report *RunReport) error {
+			mcfg.ResolveMetrics(cfg)
 
-		// Check if this monitor exists (legacy monitors don't have groups)
-		existing, exists := existingByName[mcfg.Name]
-		if exists {
-			logging.Infof("Legacy monitor exists: %s (ID: %d) - will be updated/overwritten", mcfg.Name, existing.GetID())
+			// Check if this monitor exists (legacy monitors don't have groups)
+			existing, exists := existingByName[mcfg.Name]
+			if exists {
+				monitorEvent("exists", mcfg.Type, mcfg.Name, existing.GetID(), false).Infof("Legacy monitor exists: %s (ID: %d) - will be updated/overwritten", mcfg.Name, existing.GetID())
+
+				// Resolve target notifications
+				targetIDs := []int64{}
+				if len(mcfg.NotificationNames) > 0 {
+					ids, err := notifications.resolve(mcfg.NotificationNames, false)
+					if err != nil {
+						logging.Warnf("Warning: failed to resolve notifications for %s: %v", mcfg.Name, err)
+					} else {
+						targetIDs = ids
+					}
+				}
 
-			// Resolve target notifications
-			targetIDs := []int64{}
-			if len(mcfg.NotificationNames) > 0 {
-				ids, err := ResolveNotificationIDs(ctx, client, mcfg.NotificationNames)
+				// Update description + notifications
+				updated, err := UpdateMonitorBase(ctx, client, monitorCache, cfg, existing.GetID(), mcfg, targetIDs, tagIDs, opts.DryRun)
 				if err != nil {
-					logging.Warnf("Warning: failed to resolve notifications for %s: %v", mcfg.Name, err)
+					monitorEvent("update", mcfg.Type, mcfg.Name, existing.GetID(), false).Warnf("Warning: failed to update monitor %s: %v", mcfg.Name, err)
+					report.recordError("update monitor %s: %v", mcfg.Name, err)
 				} else {
-					targetIDs = ids
+					report.recordUpdated(mcfg.Type, mcfg.Name, existing.GetID(), updated)
 				}
-			}
 
-			// Update description + notifications
-			if err := UpdateMonitorBase(ctx, client, existing.GetID(), mcfg, targetIDs); err != nil {
-				logging.Warnf("Warning: failed to update monitor %s: %v", mcfg.Name, err)
+				return nil // skip creation
 			}
 
-			continue // skip creation
-		}
-
-		// Create new legacy monitor
-		if mcfg.URL == "" && mcfg.Type == "http" {
-			return fmt.Errorf("legacy http monitor %s missing url", mcfg.Name)
-		}
+			// Create new legacy monitor
+			notificationIDs := []int64{}
+			if len(mcfg.NotificationNames) > 0 {
+				ids, err := notifications.resolve(mcfg.NotificationNames, false)
+				if err != nil {
+					return err
+				}
+				notificationIDs = ids
+			}
 
-		notificationIDs := []int64{}
-		if len(mcfg.NotificationNames) > 0 {
-			ids, err := ResolveNotificationIDs(ctx, client, mcfg.NotificationNames)
-			if err != nil {
-				return err
+			// Legacy monitors default to first group
+			var parent *int64
+			if len(cfg.Groups) > 0 {
+				if groupID, exists := groupNameToID[cfg.Groups[0].Name]; exists {
+					parent = &groupID
+					logging.Debugf("Legacy monitor %s defaults to first group %q (ID: %d)", mcfg.Name, cfg.Groups[0].Name, *parent)
+				}
 			}
-			notificationIDs = ids
-		}
 
-		// Legacy monitors default to first group
-		var parent *int64
-		if len(cfg.Groups) > 0 {
-			if groupID, exists := groupNameToID[cfg.Groups[0].Name]; exists {
-				parent = &groupID
-				logging.Debugf("Legacy monitor %s defaults to first group %q (ID: %d)", mcfg.Name, cfg.Groups[0].Name, *parent)
+			base := monitor.Base{
+				Name:            mcfg.Name,
+				Description:     mcfg.Description,
+				NotificationIDs: notificationIDs,
+				Interval:        int64(cfg.Interval),
+				MaxRetries:      int64(cfg.MaxRetries),
+				IsActive:        mcfg.IsActive(),
+				Parent:          parent,
 			}
-		}
 
-		base := monitor.Base{
-			Name:            mcfg.Name,
-			Description:     mcfg.Description,
-			NotificationIDs: notificationIDs,
-			Interval:        int64(cfg.Interval),
-			MaxRetries:      int64(cfg.MaxRetries),
-			IsActive:        true,
-			Parent:          parent,
-		}
+			var mon monitor.Monitor
+			switch mcfg.Type {
+			case "push":
+				customToken, err := GeneratePushToken(cfg.PushTokenBytes())
+				if err != nil {
+					return fmt.Errorf("failed to generate push token: %w", err)
+				}
+				logging.Debugf("Generated custom push token for legacy '%s': %s", mcfg.Name, customToken)
 
-		var mon monitor.Monitor
-		switch mcfg.Type {
-		case "push":
-			customToken, err := GeneratePushToken()
-			if err != nil {
-				return fmt.Errorf("failed to generate push token: %w", err)
+				pushMon := &monitor.Push{
+					Base: base,
+					PushDetails: monitor.PushDetails{
+						PushToken: customToken,
+					},
+				}
+				mon = pushMon
+			case "http", "keyword", "tcp", "port", "dns", "ping", "mqtt", "raw":
+				proxyID, err := ResolveProxyID(ctx, client, cfg, mcfg.Proxy, opts.DryRun)
+				if err != nil {
+					return err
+				}
+				base.ProxyID = proxyID
+				checkMon, err := buildCheckMonitor(mcfg, base)
+				if err != nil {
+					return fmt.Errorf("legacy %w", err)
+				}
+				mon = checkMon
+			default:
+				return fmt.Errorf("unsupported legacy type: %s", mcfg.Type)
 			}
-			logging.Debugf("Generated custom push token for legacy '%s': %s", mcfg.Name, customToken)
 
-			pushMon := &monitor.Push{
-				Base: base,
-				PushDetails: monitor.PushDetails{
-					PushToken: customToken,
-				},
+			if opts.DryRun {
+				monitorEvent("create", mcfg.Type, mcfg.Name, 0, true).Infof("WOULD CREATE legacy %s monitor %s", mcfg.Type, mcfg.Name)
+				report.recordCreated(mcfg.Type, mcfg.Name, 0)
+				return nil
 			}
-			mon = pushMon
-		case "http":
-			httpMon := &monitor.HTTP{
-				Base: base,
-				HTTPDetails: monitor.HTTPDetails{
-					URL:                 mcfg.URL,
-					Method:              "GET",
-					Body:                "",
-					HTTPBodyEncoding:    "text",
-					Headers:             "{}",
-					AcceptedStatusCodes: []string{"200-299"},
-					MaxRedirects:        10,
-					Timeout:             30,
-				},
-			}
-			mon = httpMon
-		default:
-			return fmt.Errorf("unsupported legacy type: %s", mcfg.Type)
-		}
 
-		id, err := client.CreateMonitor(ctx, mon)
-		if err != nil {
-			return fmt.Errorf("create legacy %s monitor %s: %w", mcfg.Type, mcfg.Name, err)
-		}
+			id, err := client.CreateMonitor(ctx, mon)
+			if err != nil {
+				return fmt.Errorf("create legacy %s monitor %s: %w", mcfg.Type, mcfg.Name, err)
+			}
 
-		// Fetch token for newly created push monitor
-		if mcfg.Type == "push" {
-			var push monitor.Push
-			if err := client.GetMonitorAs(ctx, id, &push); err == nil {
-				if push.PushDetails.PushToken != "" {
-					mcfg.PushToken = push.PushDetails.PushToken
-					configUpdated = true
+			// Fetch token for newly created push monitor
+			if mcfg.Type == "push" {
+				if token, err := FetchPushToken(ctx, client, id); err == nil {
+					mcfg.PushToken = token
+					tokensUpdated.Store(true)
 					logging.Debugf("Fetched push token for legacy monitor %s: %s", mcfg.Name, mcfg.PushToken)
 				} else {
-					logging.Warnf("New legacy push monitor %s created but token empty", mcfg.Name)
+					logging.Errorf("Failed to fetch token for legacy monitor %s: %v", mcfg.Name, err)
+					report.recordError("fetch token for %s: %v", mcfg.Name, err)
 				}
-			} else {
-				logging.Errorf("Failed to fetch token for legacy monitor %s: %v", mcfg.Name, err)
 			}
+
+			monitorEvent("create", mcfg.Type, mcfg.Name, id, false).Infof("Created legacy %s monitor: %s (ID: %d)", mcfg.Type, mcfg.Name, id)
+			pauseIfInactive(ctx, client, id, mcfg, report)
+			attachTags(ctx, client, id, mcfg, tagIDs, report)
+			report.recordCreated(mcfg.Type, mcfg.Name, id)
+			return nil
+		})
+	}
+
+	// A failed monitor doesn't stop the rest of the run: pruning only touches monitors
+	// missing from the config, and tokens fetched by the tasks that succeeded still
+	// need saving. The task errors are returned at the end.
+	tasksErr := runTasks(tasks, opts.Concurrency, report)
+	configUpdated := tokensUpdated.Load()
+
+	if opts.Prune {
+		pruneOrphanedMonitors(ctx, client, cfg, monitors, groupNameToID, opts.DryRun, report)
+	}
+
+	// Maintenance windows and the status page reference monitors by name, so they come
+	// after the monitors are created and pruned
+	EnsureMaintenance(ctx, client, cfg, opts.DryRun, report)
+	EnsureStatusPage(ctx, client, cfg, opts.DryRun, report)
+
+	// Push tokens go to the tokens file so the config files are never rewritten. Saving
+	// also when nothing was fetched migrates tokens still set inline.
+	if opts.DryRun {
+		if configUpdated {
+			logging.Infof("WOULD SAVE updated push tokens to %s", cfg.TokensPath())
 		}
+	} else if saved, err := config.SaveTokens(cfg); err != nil {
+		logging.Warnf("Warning: failed to save push tokens: %v", err)
+		report.recordError("save push tokens: %v", err)
+	} else if saved {
+		logging.Infof("Saved push tokens to %s", cfg.TokensPath())
+	}
+	if inline := cfg.InlinePushTokens(); len(inline) > 0 && !opts.DryRun {
+		logging.Infof("push_token is still set in the config for %s; tokens are kept in %s now, so these can be removed",
+			strings.Join(inline, ", "), cfg.TokensPath())
+	}
 
-		logging.Infof("Created legacy %s monitor: %s (ID: %d)", mcfg.Type, mcfg.Name, id)
+	return tasksErr
+}
+
+// runTasks runs tasks with at most limit running at a time. Each task records into its
+// own report, and the reports are merged into report in task order, so the outcome
+// doesn't depend on scheduling. All tasks run even when some fail; their errors are
+// joined in task order.
+func runTasks(tasks []func(report *RunReport) error, limit int, report *RunReport) error {
+	limit = max(limit, 1)
+	reports := make([]RunReport, len(tasks))
+	errs := make([]error, len(tasks))
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = task(&reports[i])
+		}()
 	}
+	wg.Wait()
 
-	// Always save config if tokens were updated
-	if configUpdated {
-		if err := config.SaveConfig("/config/config.yaml", cfg); err != nil {
-			logging.Warnf("Warning: failed to save updated config with tokens: %v", err)
-		} else {
-			logging.Info("Saved updated config with push tokens")
+	for i := range reports {
+		report.merge(&reports[i])
+	}
+	return errors.Join(errs...)
+}
+
+// pruneOrphanedMonitors deletes child monitors of the provisioned groups whose name no
+// longer appears in any monitor section of the config.
+func pruneOrphanedMonitors(ctx context.Context, client *kuma.Client, cfg *config.Config, existing []monitor.Base, groupNameToID map[string]int64, dryRun bool, report *RunReport) {
+	// Match on name and group like monitorKey; provisioning puts monitors without a
+	// group, and all legacy monitors, under the first group.
+	var firstGroup string
+	if len(cfg.Groups) > 0 {
+		firstGroup = cfg.Groups[0].Name
+	}
+	configured := make(map[string]bool)
+	for _, m := range cfg.GetAllMonitors() {
+		if m.Group == "" {
+			m.Group = firstGroup
+		}
+		configured[m.Name+"|"+m.Group] = true
+	}
+	for _, m := range cfg.Monitors {
+		configured[m.Name+"|"+firstGroup] = true
+	}
+
+	groupIDs := make(map[int64]string)
+	for name, id := range groupNameToID {
+		groupIDs[id] = name
+	}
+
+	removed := 0
+	for _, m := range existing {
+		// Nested groups aren't monitors this agent provisions
+		if m.Parent == nil || m.Type() == "group" {
+			continue
+		}
+		groupName, provisioned := groupIDs[*m.Parent]
+		if !provisioned || configured[m.Name+"|"+groupName] {
+			continue
 		}
+
+		if dryRun {
+			monitorEvent("delete", m.Type(), m.Name, m.GetID(), true).Infof("WOULD DELETE orphaned monitor %s (group: %s, ID: %d)", m.Name, groupName, m.GetID())
+			report.recordDeleted(m.Type(), m.Name, m.GetID())
+			removed++
+			continue
+		}
+
+		if err := client.DeleteMonitor(ctx, m.GetID()); err != nil {
+			monitorEvent("delete", m.Type(), m.Name, m.GetID(), false).Warnf("Warning: failed to prune monitor %s (ID: %d): %v", m.Name, m.GetID(), err)
+			report.recordError("prune monitor %s: %v", m.Name, err)
+			continue
+		}
+		monitorEvent("delete", m.Type(), m.Name, m.GetID(), false).Infof("Pruned orphaned monitor: %s (group: %s, ID: %d)", m.Name, groupName, m.GetID())
+		report.recordDeleted(m.Type(), m.Name, m.GetID())
+		removed++
 	}
 
-	return nil
+	logging.Infof("Pruning complete: %d orphaned monitor(s) removed", removed)
 }
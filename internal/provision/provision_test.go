@@ -0,0 +1,190 @@
+package provision
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/breml/go-uptime-kuma-client/monitor"
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+)
+
+func TestRunTasksMergesInTaskOrder(t *testing.T) {
+	const n, limit = 8, 3
+
+	var running, peak atomic.Int32
+	tasks := make([]func(report *RunReport) error, n)
+	for i := range tasks {
+		tasks[i] = func(report *RunReport) error {
+			cur := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if cur <= p || peak.CompareAndSwap(p, cur) {
+					break
+				}
+			}
+
+			// Later tasks finish first, so scheduling order differs from task order.
+			time.Sleep(time.Duration(n-i) * 5 * time.Millisecond)
+			report.recordCreated("http", fmt.Sprintf("m%d", i), int64(i))
+			if i%2 == 1 {
+				report.recordError("task %d", i)
+				return fmt.Errorf("task %d failed", i)
+			}
+			return nil
+		}
+	}
+
+	var report RunReport
+	err := runTasks(tasks, limit, &report)
+
+	if p := peak.Load(); p > limit {
+		t.Errorf("peak concurrency = %d, want at most %d", p, limit)
+	}
+	if report.Created != n {
+		t.Errorf("Created = %d, want %d", report.Created, n)
+	}
+	if len(report.Actions) != n {
+		t.Fatalf("got %d actions, want %d", len(report.Actions), n)
+	}
+	for i, a := range report.Actions {
+		if want := fmt.Sprintf("m%d", i); a.Name != want || a.ID != int64(i) {
+			t.Errorf("action %d = %s (ID %d), want %s (ID %d)", i, a.Name, a.ID, want, i)
+		}
+	}
+	wantErrors := []string{"task 1", "task 3", "task 5", "task 7"}
+	if strings.Join(report.Errors, ",") != strings.Join(wantErrors, ",") {
+		t.Errorf("report errors = %v, want %v", report.Errors, wantErrors)
+	}
+
+	if err == nil {
+		t.Fatal("runTasks returned nil, want the joined task errors")
+	}
+	wantErr := "task 1 failed\ntask 3 failed\ntask 5 failed\ntask 7 failed"
+	if err.Error() != wantErr {
+		t.Errorf("error = %q, want %q", err.Error(), wantErr)
+	}
+}
+
+func TestRunTasksDefaultsToOneAtATime(t *testing.T) {
+	var running atomic.Int32
+	tasks := make([]func(report *RunReport) error, 4)
+	for i := range tasks {
+		tasks[i] = func(report *RunReport) error {
+			if running.Add(1) != 1 {
+				return errors.New("tasks overlapped")
+			}
+			defer running.Add(-1)
+			time.Sleep(time.Millisecond)
+			return nil
+		}
+	}
+
+	if err := runTasks(tasks, 0, &RunReport{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGeneratePushToken(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, n := range []int{config.DefaultPushTokenBytes, 24, 32, 64} {
+		token, err := GeneratePushToken(n)
+		if err != nil {
+			t.Fatalf("GeneratePushToken(%d): %v", n, err)
+		}
+		if len(token) != 2*n {
+			t.Errorf("GeneratePushToken(%d) has %d characters, want %d", n, len(token), 2*n)
+		}
+		if strings.Trim(token, "0123456789abcdef") != "" {
+			t.Errorf("GeneratePushToken(%d) = %q, want lowercase hex only", n, token)
+		}
+		if url.PathEscape(token) != token {
+			t.Errorf("GeneratePushToken(%d) = %q, needs escaping in a push URL", n, token)
+		}
+		if seen[token] {
+			t.Errorf("GeneratePushToken(%d) repeated token %q", n, token)
+		}
+		seen[token] = true
+	}
+
+	for _, n := range []int{0, 8, config.DefaultPushTokenBytes - 1} {
+		if token, err := GeneratePushToken(n); err == nil {
+			t.Errorf("GeneratePushToken(%d) = %q, want an error below the minimum of %d bytes", n, token, config.DefaultPushTokenBytes)
+		}
+	}
+}
+
+func TestPushTokenBytesDefault(t *testing.T) {
+	cfg := &config.Config{}
+	if got := cfg.PushTokenBytes(); got != config.DefaultPushTokenBytes {
+		t.Errorf("PushTokenBytes without agent.push_token_bytes = %d, want %d", got, config.DefaultPushTokenBytes)
+	}
+	cfg.Agent.PushTokenBytes = 32
+	if got := cfg.PushTokenBytes(); got != 32 {
+		t.Errorf("PushTokenBytes = %d, want the configured 32", got)
+	}
+}
+
+func TestConfigHashIncludesRunOptions(t *testing.T) {
+	cfg := &config.Config{UptimeKumaURL: "http://kuma:3001"}
+	hash := func(opts Options) string {
+		h, err := ConfigHash(cfg, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	plain := hash(Options{})
+	if hash(Options{}) != plain {
+		t.Error("ConfigHash differs between identical runs")
+	}
+	for _, opts := range []Options{{Prune: true}, {StrictNotifications: true}, {Concurrency: 4}} {
+		if hash(opts) == plain {
+			t.Errorf("ConfigHash with %+v matches a plain run", opts)
+		}
+	}
+}
+
+func TestPruneMatchesNameAndGroup(t *testing.T) {
+	cfg := &config.Config{
+		Groups: []config.GroupConfig{{Name: "Web"}, {Name: "DB"}},
+		HTTPMonitors: []config.MonitorConfig{
+			{Name: "API", Group: "Web"},
+			{Name: "Site"}, // lands in the first group
+		},
+	}
+	var existing []monitor.Base
+	err := json.Unmarshal([]byte(`[
+		{"id": 1, "name": "Web", "type": "group"},
+		{"id": 2, "name": "DB", "type": "group"},
+		{"id": 3, "name": "Nested", "type": "group", "parent": 1},
+		{"id": 4, "name": "API", "type": "http", "parent": 1},
+		{"id": 5, "name": "API", "type": "http", "parent": 2},
+		{"id": 6, "name": "Site", "type": "http", "parent": 1},
+		{"id": 7, "name": "Site", "type": "http", "parent": 2},
+		{"id": 8, "name": "Old", "type": "http", "parent": 2},
+		{"id": 9, "name": "Manual", "type": "http"}
+	]`), &existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A dry run reports deletions without calling the client
+	var report RunReport
+	pruneOrphanedMonitors(t.Context(), nil, cfg, existing, map[string]int64{"Web": 1, "DB": 2}, true, &report)
+
+	var deleted []int64
+	for _, a := range report.Actions {
+		deleted = append(deleted, a.ID)
+	}
+	if want := []int64{5, 7, 8}; fmt.Sprint(deleted) != fmt.Sprint(want) {
+		t.Errorf("pruned monitor IDs %v, want %v", deleted, want)
+	}
+}
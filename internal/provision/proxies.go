@@ -0,0 +1,118 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	kuma "github.com/breml/go-uptime-kuma-client"
+	"github.com/breml/go-uptime-kuma-client/monitor"
+	"github.com/breml/go-uptime-kuma-client/proxy"
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+)
+
+// proxyAddress returns the host:port proxies are matched by.
+func proxyAddress(host string, port int) string {
+	return net.JoinHostPort(strings.ToLower(host), strconv.Itoa(port))
+}
+
+// EnsureProxies creates the configured proxies that don't exist yet in Uptime Kuma.
+// Existing proxies are matched by host and port and left untouched, so re-runs are
+// idempotent.
+func EnsureProxies(ctx context.Context, client *kuma.Client, cfg *config.Config, dryRun bool, report *RunReport) error {
+	if len(cfg.Proxies) == 0 {
+		return nil
+	}
+
+	existing := make(map[string]bool)
+	for _, p := range client.GetProxyList(ctx) {
+		existing[proxyAddress(p.Host, p.Port)] = true
+	}
+
+	for _, pcfg := range cfg.Proxies {
+		addr := proxyAddress(pcfg.Host, pcfg.Port)
+		if existing[addr] {
+			logging.Debugf("Proxy %s (%s) already exists", pcfg.Name, addr)
+			continue
+		}
+
+		protocol := strings.ToLower(pcfg.Protocol)
+		if protocol == "" {
+			protocol = "http"
+		}
+
+		if dryRun {
+			monitorEvent("create", "proxy", pcfg.Name, 0, true).Infof("WOULD CREATE %s proxy %s (%s)", protocol, pcfg.Name, addr)
+			report.recordCreated("proxy", pcfg.Name, 0)
+			continue
+		}
+
+		id, err := client.CreateProxy(ctx, proxy.Config{
+			Protocol: protocol,
+			Host:     pcfg.Host,
+			Port:     pcfg.Port,
+			Auth:     pcfg.Username != "",
+			Username: pcfg.Username,
+			Password: pcfg.Password,
+			Active:   true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create proxy %s: %w", pcfg.Name, err)
+		}
+		existing[addr] = true
+		monitorEvent("create", "proxy", pcfg.Name, id, false).Infof("Created %s proxy %s (%s, ID: %d)", protocol, pcfg.Name, addr, id)
+		report.recordCreated("proxy", pcfg.Name, id)
+	}
+
+	return nil
+}
+
+// ResolveProxyID maps the proxies entry named name to its Uptime Kuma ID, or nil for no
+// proxy. In a dry run a configured proxy that doesn't exist yet also resolves to nil,
+// since a real run would create it first.
+func ResolveProxyID(ctx context.Context, client *kuma.Client, cfg *config.Config, name string, dryRun bool) (*int64, error) {
+	if name == "" {
+		return nil, nil
+	}
+	pcfg := cfg.FindProxy(name)
+	if pcfg == nil {
+		return nil, fmt.Errorf("proxy %q is not defined under proxies", name)
+	}
+
+	addr := proxyAddress(pcfg.Host, pcfg.Port)
+	for _, p := range client.GetProxyList(ctx) {
+		if proxyAddress(p.Host, p.Port) == addr {
+			id := p.ID
+			return &id, nil
+		}
+	}
+	if dryRun {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("proxy %s (%s) not found in Uptime Kuma", name, addr)
+}
+
+// reconcileProxy points base at the monitor's configured proxy, or at none without one,
+// and reports whether anything changed. In a dry run a proxy still to be created is
+// not compared.
+func reconcileProxy(ctx context.Context, client *kuma.Client, cfg *config.Config, base *monitor.Base, mcfg *config.MonitorConfig, dryRun bool) (bool, error) {
+	id, err := ResolveProxyID(ctx, client, cfg, mcfg.Proxy, dryRun)
+	if err != nil {
+		return false, err
+	}
+	if id == nil && mcfg.Proxy != "" {
+		return false, nil // dry run: created by the real run
+	}
+
+	switch {
+	case id == nil && base.ProxyID == nil:
+		return false, nil
+	case id != nil && base.ProxyID != nil && *id == *base.ProxyID:
+		return false, nil
+	}
+	base.ProxyID = id
+	return true, nil
+}
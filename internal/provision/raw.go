@@ -0,0 +1,104 @@
+package provision
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strconv"
+
+	"github.com/breml/go-uptime-kuma-client/monitor"
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+)
+
+// rawMonitor is a monitor of a type the agent has no dedicated support for. fields holds
+// its type-specific settings, sent as-is; the base fields (name, group, interval,
+// notifications, ...) are managed like those of any other monitor and win over fields.
+type rawMonitor struct {
+	monitor.Base
+	rawType string
+	fields  map[string]any
+}
+
+// Type returns the configured Uptime Kuma monitor type.
+func (m rawMonitor) Type() string {
+	return m.rawType
+}
+
+// MarshalJSON marshals the monitor as its fields with the base fields on top.
+func (m rawMonitor) MarshalJSON() ([]byte, error) {
+	raw := map[string]any{
+		// Defaults of a new monitor in the Uptime Kuma UI, which the server relies on
+		"accepted_statuscodes": []string{"200-299"},
+		"conditions":           []any{},
+	}
+	maps.Copy(raw, m.fields)
+
+	raw["id"] = m.ID
+	raw["type"] = m.rawType
+	raw["name"] = m.Name
+	raw["description"] = m.Description
+	raw["parent"] = m.Parent
+	raw["proxyId"] = m.ProxyID
+	raw["interval"] = m.Interval
+	raw["retryInterval"] = m.RetryInterval
+	raw["resendInterval"] = m.ResendInterval
+	raw["maxretries"] = m.MaxRetries
+	raw["upsideDown"] = m.UpsideDown
+	raw["active"] = m.IsActive
+	delete(raw, "pathName") // generated by the server
+	delete(raw, "tags")     // managed through the tag API
+
+	ids := map[string]bool{}
+	for _, id := range m.NotificationIDs {
+		ids[strconv.FormatInt(id, 10)] = true
+	}
+	raw["notificationIDList"] = ids
+
+	return json.Marshal(raw)
+}
+
+// rawSettings returns the settings of mcfg in the form Uptime Kuma's JSON decodes to, so
+// they compare equal to the fields of an existing monitor.
+func rawSettings(mcfg *config.MonitorConfig) (map[string]any, error) {
+	data, err := json.Marshal(mcfg.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("raw monitor %s: invalid settings: %w", mcfg.Name, err)
+	}
+	settings := map[string]any{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("raw monitor %s: invalid settings: %w", mcfg.Name, err)
+	}
+	return settings, nil
+}
+
+// newRawMonitor builds a new raw monitor from mcfg.
+func newRawMonitor(mcfg *config.MonitorConfig, base monitor.Base) (*rawMonitor, error) {
+	if mcfg.RawType == "" {
+		return nil, fmt.Errorf("raw monitor %s missing raw_type", mcfg.Name)
+	}
+	settings, err := rawSettings(mcfg)
+	if err != nil {
+		return nil, err
+	}
+	return &rawMonitor{Base: base, rawType: mcfg.RawType, fields: settings}, nil
+}
+
+// reconcileRawMonitor applies the type and settings of mcfg to mon and reports whether
+// anything changed. Fields not in settings keep their current value.
+func reconcileRawMonitor(mon *rawMonitor, mcfg *config.MonitorConfig) (bool, error) {
+	settings, err := rawSettings(mcfg)
+	if err != nil {
+		return false, err
+	}
+
+	changed := mon.Base.Type() != mcfg.RawType
+	mon.rawType = mcfg.RawType
+	for key, value := range settings {
+		if !reflect.DeepEqual(mon.fields[key], value) {
+			mon.fields[key] = value
+			changed = true
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,81 @@
+package provision
+
+import (
+	"fmt"
+	"time"
+)
+
+// RunReport summarizes the outcome of a provisioning run.
+type RunReport struct {
+	Status     string    `json:"status"` // success, failed
+	DryRun     bool      `json:"dry_run,omitempty"`
+	Skipped    bool      `json:"skipped,omitempty"` // nothing changed since the last run
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Duration   float64   `json:"duration_seconds"`
+	Created    int       `json:"created"`
+	Updated    int       `json:"updated"`
+	Unchanged  int       `json:"unchanged"`
+	Deleted    int       `json:"deleted"`
+	Errors     []string  `json:"errors,omitempty"`
+	Actions    []Action  `json:"actions,omitempty"`
+}
+
+// Action is what a run did, or would do in a dry run, to one monitor or other object.
+type Action struct {
+	Action string `json:"action"` // create, update, unchanged, delete
+	Type   string `json:"type"`   // monitor type, or group, notification, proxy, maintenance, status_page
+	Name   string `json:"name"`
+	ID     int64  `json:"id,omitempty"` // 0 for objects not created yet
+}
+
+// NewRunReport starts a report for a run beginning now.
+func NewRunReport() *RunReport {
+	return &RunReport{StartedAt: time.Now().UTC()}
+}
+
+// Finish stamps the end time and final status of the run.
+func (r *RunReport) Finish(runErr error) {
+	r.FinishedAt = time.Now().UTC()
+	r.Duration = r.FinishedAt.Sub(r.StartedAt).Seconds()
+	r.Status = "success"
+	if runErr != nil {
+		r.Status = "failed"
+		r.Errors = append(r.Errors, runErr.Error())
+	}
+}
+
+func (r *RunReport) recordCreated(typ, name string, id int64) {
+	r.Created++
+	r.Actions = append(r.Actions, Action{Action: "create", Type: typ, Name: name, ID: id})
+}
+
+func (r *RunReport) recordUpdated(typ, name string, id int64, updated bool) {
+	action := "unchanged"
+	if updated {
+		r.Updated++
+		action = "update"
+	} else {
+		r.Unchanged++
+	}
+	r.Actions = append(r.Actions, Action{Action: action, Type: typ, Name: name, ID: id})
+}
+
+func (r *RunReport) recordDeleted(typ, name string, id int64) {
+	r.Deleted++
+	r.Actions = append(r.Actions, Action{Action: "delete", Type: typ, Name: name, ID: id})
+}
+
+func (r *RunReport) recordError(format string, args ...any) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+// merge adds the counts and errors of a task's report to r.
+func (r *RunReport) merge(o *RunReport) {
+	r.Created += o.Created
+	r.Updated += o.Updated
+	r.Unchanged += o.Unchanged
+	r.Deleted += o.Deleted
+	r.Errors = append(r.Errors, o.Errors...)
+	r.Actions = append(r.Actions, o.Actions...)
+}
@@ -0,0 +1,79 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+
+	kuma "github.com/breml/go-uptime-kuma-client"
+	"github.com/breml/go-uptime-kuma-client/monitor"
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+)
+
+// RotatePushToken gives the existing push monitor of mcfg a new push token in Uptime Kuma
+// and stores it on mcfg, returning the old token. The new token is read back so a token
+// Uptime Kuma didn't save is never written out.
+func RotatePushToken(ctx context.Context, client *kuma.Client, cfg *config.Config, mcfg *config.MonitorConfig) (string, error) {
+	monID, err := findPushMonitorID(ctx, client, mcfg)
+	if err != nil {
+		return "", err
+	}
+
+	var push monitor.Push
+	if err := client.GetMonitorAs(ctx, monID, &push); err != nil {
+		return "", err
+	}
+	oldToken := push.PushDetails.PushToken
+
+	newToken, err := GeneratePushToken(cfg.PushTokenBytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to generate push token: %w", err)
+	}
+	push.PushDetails.PushToken = newToken
+	if err := client.UpdateMonitor(ctx, &push); err != nil {
+		return "", fmt.Errorf("failed to update push monitor %d: %w", monID, err)
+	}
+
+	saved, err := FetchPushToken(ctx, client, monID)
+	if err != nil {
+		return "", err
+	}
+	if saved != newToken {
+		return "", fmt.Errorf("push monitor %d kept a different token than the one sent", monID)
+	}
+
+	mcfg.PushToken = newToken
+	monitorEvent("update", "push", mcfg.Name, monID, false).Infof("Rotated push token of %s (ID: %d)", mcfg.Name, monID)
+	return oldToken, nil
+}
+
+// findPushMonitorID returns the ID of the push monitor of mcfg in Uptime Kuma. Like
+// provisioning, a monitor without a group is matched by name alone.
+func findPushMonitorID(ctx context.Context, client *kuma.Client, mcfg *config.MonitorConfig) (int64, error) {
+	monitors, err := client.GetMonitors(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get monitors: %w", err)
+	}
+
+	var groupID int64
+	if mcfg.Group != "" {
+		for _, m := range monitors {
+			if m.Type() == "group" && m.Name == mcfg.Group {
+				groupID = m.ID
+				break
+			}
+		}
+		if groupID == 0 {
+			return 0, fmt.Errorf("group %s not found in Uptime Kuma", mcfg.Group)
+		}
+	}
+
+	for _, m := range monitors {
+		if m.Type() != "push" || m.Name != mcfg.Name {
+			continue
+		}
+		if mcfg.Group == "" || (m.Parent != nil && *m.Parent == groupID) {
+			return m.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("push monitor %s not found in Uptime Kuma", mcfg.Name)
+}
@@ -0,0 +1,158 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	kuma "github.com/breml/go-uptime-kuma-client"
+	"github.com/breml/go-uptime-kuma-client/monitor"
+	"github.com/breml/go-uptime-kuma-client/statuspage"
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+)
+
+// EnsureStatusPage creates the configured status page when it's missing and saves its
+// settings and sections. Uptime Kuma doesn't return a page's sections over the socket
+// API, so they can't be compared: the page is saved on every run, replacing sections
+// edited in the UI. Settings not in the config (icon, footer, domains, ...) are kept.
+func EnsureStatusPage(ctx context.Context, client *kuma.Client, cfg *config.Config, dryRun bool, report *RunReport) {
+	spcfg := cfg.StatusPage
+	if spcfg == nil {
+		return
+	}
+	if err := ensureStatusPage(ctx, client, cfg, spcfg, dryRun, report); err != nil {
+		monitorEvent("update", "status_page", spcfg.Slug, 0, dryRun).Warnf("Warning: failed to provision status page %s: %v", spcfg.Slug, err)
+		report.recordError("status page %s: %v", spcfg.Slug, err)
+	}
+}
+
+func ensureStatusPage(ctx context.Context, client *kuma.Client, cfg *config.Config, spcfg *config.StatusPageConfig, dryRun bool, report *RunReport) error {
+	pages, err := client.GetStatusPages(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get status pages: %w", err)
+	}
+	exists := false
+	for _, p := range pages {
+		if p.Slug == spcfg.Slug {
+			exists = true
+			break
+		}
+	}
+
+	monitors, err := client.GetMonitors(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get monitors: %w", err)
+	}
+	sections, missing := statusPageSections(cfg, spcfg, monitors)
+	if len(missing) > 0 {
+		if !dryRun {
+			return fmt.Errorf("monitor names not found: %s", strings.Join(missing, ", "))
+		}
+		// A real run may create them first
+		logging.Debugf("Status page %s: monitors not found yet: %v", spcfg.Slug, missing)
+	}
+
+	if dryRun {
+		if exists {
+			monitorEvent("update", "status_page", spcfg.Slug, 0, true).Infof("WOULD SAVE status page %s (%d sections)", spcfg.Slug, len(sections))
+			report.recordUpdated("status_page", spcfg.Slug, 0, true)
+		} else {
+			monitorEvent("create", "status_page", spcfg.Slug, 0, true).Infof("WOULD CREATE status page %s (%d sections)", spcfg.Slug, len(sections))
+			report.recordCreated("status_page", spcfg.Slug, 0)
+		}
+		return nil
+	}
+
+	if !exists {
+		if err := client.AddStatusPage(ctx, spcfg.Title, spcfg.Slug); err != nil {
+			return fmt.Errorf("failed to create status page: %w", err)
+		}
+	}
+
+	sp, err := client.GetStatusPage(ctx, spcfg.Slug)
+	if err != nil {
+		return err
+	}
+	sp.Title = spcfg.Title
+	sp.Description = spcfg.Description
+	sp.Theme = spcfg.Theme
+	if sp.Theme == "" {
+		sp.Theme = statuspage.ThemeAuto()
+	}
+	sp.Published = spcfg.Published == nil || *spcfg.Published
+	sp.PublicGroupList = sections
+
+	if _, err := client.SaveStatusPage(ctx, sp); err != nil {
+		return fmt.Errorf("failed to save status page: %w", err)
+	}
+
+	if exists {
+		monitorEvent("update", "status_page", spcfg.Slug, sp.ID, false).Infof("Saved status page %s (%d sections)", spcfg.Slug, len(sections))
+		report.recordUpdated("status_page", spcfg.Slug, sp.ID, true)
+	} else {
+		monitorEvent("create", "status_page", spcfg.Slug, sp.ID, false).Infof("Created status page %s (ID: %d, %d sections)", spcfg.Slug, sp.ID, len(sections))
+		report.recordCreated("status_page", spcfg.Slug, sp.ID)
+	}
+	return nil
+}
+
+// statusPageSections maps the status page's monitor names to sections: each group gets a
+// section with its monitors, and monitors named directly share one section placed where
+// the first of them is listed. It also returns the names that matched no monitor.
+func statusPageSections(cfg *config.Config, spcfg *config.StatusPageConfig, monitors []monitor.Base) ([]statuspage.PublicGroup, []string) {
+	names := spcfg.Monitors
+	if len(names) == 0 {
+		for _, g := range cfg.Groups {
+			names = append(names, g.Name)
+		}
+	}
+
+	byName := make(map[string][]monitor.Base)
+	children := make(map[int64][]monitor.Base) // group ID -> monitors in it
+	for _, m := range monitors {
+		byName[m.Name] = append(byName[m.Name], m)
+		if m.Parent != nil {
+			children[*m.Parent] = append(children[*m.Parent], m)
+		}
+	}
+
+	var sections []statuspage.PublicGroup
+	var missing []string
+	direct := -1 // index of the section for monitors named directly
+	for _, name := range names {
+		found, ok := byName[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		for _, m := range found {
+			if m.Type() != "group" {
+				if direct < 0 {
+					section := spcfg.Section
+					if section == "" {
+						section = config.DefaultStatusPageSection
+					}
+					direct = len(sections)
+					sections = append(sections, statuspage.PublicGroup{Name: section})
+				}
+				sections[direct].MonitorList = append(sections[direct].MonitorList, statuspage.PublicMonitor{ID: m.ID})
+				continue
+			}
+
+			members := children[m.ID]
+			sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+			section := statuspage.PublicGroup{Name: m.Name}
+			for _, c := range members {
+				section.MonitorList = append(section.MonitorList, statuspage.PublicMonitor{ID: c.ID})
+			}
+			sections = append(sections, section)
+		}
+	}
+
+	for i := range sections {
+		sections[i].Weight = i + 1
+	}
+	return sections, missing
+}
@@ -0,0 +1,154 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+
+	kuma "github.com/breml/go-uptime-kuma-client"
+	"github.com/breml/go-uptime-kuma-client/tag"
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+)
+
+// defaultTagColor is used for tags created without a configured color.
+const defaultTagColor = "#4B5563"
+
+// tagKey identifies one tag association on a monitor.
+type tagKey struct {
+	tagID int64
+	value string
+}
+
+// EnsureTags maps the tag names used by any configured monitor to their Uptime Kuma IDs,
+// creating the tags that don't exist yet. It runs once before the monitors are
+// provisioned, so monitors sharing a new tag don't each create it when run concurrently.
+// In a dry run missing tags are only logged and left out of the map.
+func EnsureTags(ctx context.Context, client *kuma.Client, cfg *config.Config, dryRun bool) (map[string]int64, error) {
+	var tags []config.TagConfig
+	seen := make(map[string]bool)
+	for _, m := range cfg.GetAllMonitors() {
+		for _, t := range m.Tags {
+			if !seen[t.Name] {
+				seen[t.Name] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	if len(tags) == 0 {
+		return map[string]int64{}, nil
+	}
+	return resolveTagIDs(ctx, client, tags, dryRun)
+}
+
+// resolveTagIDs maps the configured tag names to their Uptime Kuma IDs, creating the
+// tags that don't exist yet. In a dry run missing tags are only logged and left out.
+func resolveTagIDs(ctx context.Context, client *kuma.Client, tags []config.TagConfig, dryRun bool) (map[string]int64, error) {
+	existing, err := client.GetTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+
+	ids := make(map[string]int64)
+	for _, t := range existing {
+		ids[t.Name] = t.ID
+	}
+
+	for _, tcfg := range tags {
+		if _, ok := ids[tcfg.Name]; ok {
+			continue
+		}
+
+		color := tcfg.Color
+		if color == "" {
+			color = defaultTagColor
+		}
+		if dryRun {
+			logging.Infof("WOULD CREATE tag %s (color %s)", tcfg.Name, color)
+			continue
+		}
+
+		id, err := client.CreateTag(ctx, tag.Tag{Name: tcfg.Name, Color: color})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tag %s: %w", tcfg.Name, err)
+		}
+		ids[tcfg.Name] = id
+		logging.Infof("Created tag %s (ID: %d, color %s)", tcfg.Name, id, color)
+	}
+
+	return ids, nil
+}
+
+// attachTags adds the configured tags to a newly created monitor, looking up their IDs in
+// tagIDs (see EnsureTags). A failure is recorded but doesn't undo the create; the next run
+// retries it.
+func attachTags(ctx context.Context, client *kuma.Client, monID int64, mcfg *config.MonitorConfig, tagIDs map[string]int64, report *RunReport) {
+	if len(mcfg.Tags) == 0 {
+		return
+	}
+	if _, err := reconcileTags(ctx, client, monID, mcfg, tagIDs, false); err != nil {
+		logging.Warnf("Warning: failed to tag monitor %s: %v", mcfg.Name, err)
+		report.recordError("tag monitor %s: %v", mcfg.Name, err)
+	}
+}
+
+// reconcileTags makes the tags on monitor monID match mcfg.Tags, adding missing
+// name/value pairs and removing any others, and reports whether anything changed. Tag IDs
+// are looked up in tagIDs (see EnsureTags).
+func reconcileTags(ctx context.Context, client *kuma.Client, monID int64, mcfg *config.MonitorConfig, tagIDs map[string]int64, dryRun bool) (bool, error) {
+	current, err := client.GetMonitorTags(ctx, monID)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch tags of monitor %s: %w", mcfg.Name, err)
+	}
+	have := make(map[tagKey]bool)
+	for _, t := range current {
+		have[tagKey{t.TagID, t.Value}] = true
+	}
+
+	updated := false
+	want := make(map[tagKey]bool)
+	for _, tcfg := range mcfg.Tags {
+		id, ok := tagIDs[tcfg.Name]
+		if !ok { // not created in a dry run
+			monitorEvent("add_tag", mcfg.Type, mcfg.Name, monID, true).WithField("tag", tcfg.Name).Infof("WOULD ADD tag %s to monitor %s", tcfg.Name, mcfg.Name)
+			updated = true
+			continue
+		}
+
+		key := tagKey{id, tcfg.Value}
+		if want[key] {
+			continue
+		}
+		want[key] = true
+		if have[key] {
+			continue
+		}
+
+		updated = true
+		if dryRun {
+			monitorEvent("add_tag", mcfg.Type, mcfg.Name, monID, true).WithField("tag", tcfg.Name).Infof("WOULD ADD tag %s to monitor %s", tcfg.Name, mcfg.Name)
+			continue
+		}
+		if _, err := client.AddMonitorTag(ctx, id, monID, tcfg.Value); err != nil {
+			return false, fmt.Errorf("failed to add tag %s to monitor %s: %w", tcfg.Name, mcfg.Name, err)
+		}
+		monitorEvent("add_tag", mcfg.Type, mcfg.Name, monID, false).WithField("tag", tcfg.Name).Infof("Added tag %s to monitor %s", tcfg.Name, mcfg.Name)
+	}
+
+	for _, t := range current {
+		if want[tagKey{t.TagID, t.Value}] {
+			continue
+		}
+
+		updated = true
+		if dryRun {
+			monitorEvent("remove_tag", mcfg.Type, mcfg.Name, monID, true).WithField("tag", t.Name).Infof("WOULD REMOVE tag %s from monitor %s", t.Name, mcfg.Name)
+			continue
+		}
+		if err := client.DeleteMonitorTagWithValue(ctx, t.TagID, monID, t.Value); err != nil {
+			return false, fmt.Errorf("failed to remove tag %s from monitor %s: %w", t.Name, mcfg.Name, err)
+		}
+		monitorEvent("remove_tag", mcfg.Type, mcfg.Name, monID, false).WithField("tag", t.Name).Infof("Removed tag %s from monitor %s", t.Name, mcfg.Name)
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,211 @@
+package telegraf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// digestTimeout bounds resolving the exec image's digest, registry round trips included.
+const digestTimeout = 20 * time.Second
+
+// manifestMediaTypes are the manifest formats asked for when resolving a tag. Multi-arch
+// indexes come first, so the digest is the one docker pull by tag would record.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// imageRef is a parsed Docker image reference.
+type imageRef struct {
+	registry   string // host[:port] of the registry API
+	repository string // e.g. library/alpine
+	tag        string
+}
+
+// parseImageRef splits image into registry, repository and tag, applying Docker's
+// defaults: Docker Hub without a registry host, library/ for official images and latest
+// without a tag.
+func parseImageRef(image string) (imageRef, error) {
+	ref := imageRef{registry: "registry-1.docker.io", tag: "latest"}
+	name := image
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.tag = name[:i], name[i+1:]
+	}
+	if first, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		name = rest
+		if first != "docker.io" && first != "index.docker.io" {
+			ref.registry = first
+		}
+	}
+	if ref.registry == "registry-1.docker.io" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if name == "" || ref.tag == "" || strings.ContainsAny(name, " <>") {
+		return imageRef{}, fmt.Errorf("invalid image reference %q", image)
+	}
+	ref.repository = name
+	return ref, nil
+}
+
+// pinImage returns image with the digest it currently resolves to appended, e.g.
+// "agent:latest@sha256:...", so every Telegraf exec runs the same image until the next
+// generation. The local Docker is asked first, as that's the image docker run would use;
+// without it (or the image) the registry is. Images already pinned are returned as is.
+func pinImage(ctx context.Context, client *http.Client, image string) (string, error) {
+	if strings.Contains(image, "@") {
+		return image, nil
+	}
+	ref, err := parseImageRef(image)
+	if err != nil {
+		return "", err
+	}
+
+	digest, localErr := localDigest(ctx, image)
+	if localErr != nil {
+		var err error
+		if digest, err = registryDigest(ctx, client, ref); err != nil {
+			return "", fmt.Errorf("%w (local Docker: %v)", err, localErr)
+		}
+	}
+	return image + "@" + digest, nil
+}
+
+// localDigest returns the registry digest the local Docker recorded when it pulled image.
+func localDigest(ctx context.Context, image string) (string, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", errors.New("docker not found")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{json .RepoDigests}}", image)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var repoDigests []string
+	if err := json.Unmarshal(stdout.Bytes(), &repoDigests); err != nil {
+		return "", fmt.Errorf("unexpected docker image inspect output: %w", err)
+	}
+	name := image
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	for _, rd := range repoDigests {
+		if repo, digest, ok := strings.Cut(rd, "@"); ok && shortName(repo) == shortName(name) {
+			return digest, nil
+		}
+	}
+	return "", fmt.Errorf("no digest recorded for %s (built locally rather than pulled?)", name)
+}
+
+// shortName drops the parts of a Docker Hub repository name Docker may leave out, so
+// docker.io/library/alpine and alpine compare equal.
+func shortName(repo string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(repo, "docker.io/"), "library/")
+}
+
+// registryDigest asks the registry for the digest of ref's tag, fetching an anonymous
+// token when the registry requires one (as Docker Hub and GHCR do even for public images).
+func registryDigest(ctx context.Context, client *http.Client, ref imageRef) (string, error) {
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.registry, ref.repository, ref.tag)
+	head := func(token string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		return resp, nil
+	}
+
+	resp, err := head("")
+	if err != nil {
+		return "", fmt.Errorf("registry %s: %w", ref.registry, err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := registryToken(ctx, client, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", fmt.Errorf("registry %s: %w", ref.registry, err)
+		}
+		if resp, err = head(token); err != nil {
+			return "", fmt.Errorf("registry %s: %w", ref.registry, err)
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry %s: %s:%s: %s", ref.registry, ref.repository, ref.tag, resp.Status)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if !strings.HasPrefix(digest, "sha256:") {
+		return "", fmt.Errorf("registry %s: no digest for %s:%s", ref.registry, ref.repository, ref.tag)
+	}
+	return digest, nil
+}
+
+// challengeParamPattern matches the key="value" parameters of a WWW-Authenticate challenge;
+// values such as a scope with several actions may contain commas.
+var challengeParamPattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// registryToken fetches an anonymous pull token as described by a Bearer challenge, e.g.
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="...".
+func registryToken(ctx context.Context, client *http.Client, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported authentication %q (only anonymous token access is)", scheme)
+	}
+	values := url.Values{}
+	realm := ""
+	for _, param := range challengeParamPattern.FindAllStringSubmatch(params, -1) {
+		switch key, value := param[1], param[2]; key {
+		case "realm":
+			realm = value
+		case "service", "scope":
+			values.Set(key, value)
+		}
+	}
+	if realm == "" {
+		return "", errors.New("token challenge without realm")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+values.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request: %s", resp.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("token response: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
@@ -2,62 +2,186 @@ package telegraf
 
 import (
 	"bytes"
+	"context"
 	"embed"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
+	"unicode"
 
 	"github.com/gitisz/uptime-kuma-agent/internal/config"
 	"github.com/gitisz/uptime-kuma-agent/internal/logging"
-	"github.com/gitisz/uptime-kuma-agent/internal/provision"
+	"github.com/gitisz/uptime-kuma-agent/internal/util"
 )
 
 //go:embed templates/*.tmpl
 var templateFS embed.FS
 
-func GenerateTelegrafConfigs(cfg *config.Config, telegrafDir string) error {
+// defaultInputInterval is the collection interval of inputs no monitor sets one for.
+const defaultInputInterval = "30s"
+
+// inputIntervals tracks the collection interval requested for each generated input. When
+// monitors sharing an input disagree, the shortest interval wins.
+type inputIntervals map[string]time.Duration
+
+// request records monitor's interval for the input, logging conflicting requests.
+func (iv inputIntervals) request(input, interval, monitor string) {
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		logging.Warnf("Ignoring collection_interval %q of monitor %q: not a positive duration", interval, monitor)
+		return
+	}
+	if current, ok := iv[input]; ok && current != d {
+		logging.Warnf("Monitors disagree on the %s collection interval (%s vs %s for %q); using the shorter", input, current, d, monitor)
+		d = min(d, current)
+	}
+	iv[input] = d
+}
+
+// interval returns the Telegraf interval for input.
+func (iv inputIntervals) interval(input string) string {
+	if d, ok := iv[input]; ok {
+		return d.String()
+	}
+	return defaultInputInterval
+}
+
+// checkWritable fails unless a file can be created in and removed from dir. The probe's
+// name doesn't end in .conf, so Telegraf ignores it.
+func checkWritable(dir string) error {
+	probe, err := os.CreateTemp(dir, ".uptime-kuma-agent-preflight-*")
+	if err != nil {
+		return fmt.Errorf("telegraf directory %s is not writable (running as uid %d): %w", dir, os.Getuid(), err)
+	}
+	probe.Close()
+	if err := os.Remove(probe.Name()); err != nil {
+		return fmt.Errorf("telegraf directory %s does not allow removing files (running as uid %d): %w", dir, os.Getuid(), err)
+	}
+	return nil
+}
+
+// logTelegrafDir logs the resolved Telegraf directory and its permissions, the first thing
+// to check when generation fails on a mounted volume.
+func logTelegrafDir(dir string) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		logging.Infof("Telegraf directory: %s", abs)
+		return
+	}
+	logging.Infof("Telegraf directory: %s (%s, running as uid %d)", abs, info.Mode(), os.Getuid())
+}
+
+// uniqueFileName returns name, or a variant of it not yet in used, and marks it used.
+// Names that sanitize alike ("API" and "api", "Disk /" and "Disk") get the metric and
+// then a counter appended.
+func uniqueFileName(name, metric string, used map[string]bool) string {
+	candidate := name
+	if used[candidate] {
+		candidate = name + "-" + util.SanitizeFilename(metric, "-")
+	}
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%s-%d", name, util.SanitizeFilename(metric, "-"), i)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// inputName returns the Telegraf input that produces metric; the docker_container_*
+// measurements all come from inputs.docker.
+func inputName(metric string) string {
+	if strings.Contains(strings.ToLower(metric), "docker") {
+		return "docker"
+	}
+	return metric
+}
+
+// GenerateTelegrafConfigs writes the Telegraf drop-in configs for all push monitors into
+// telegrafDir. With dryRun set, templates are still rendered but only the files that
+// would be written or removed are logged.
+func GenerateTelegrafConfigs(cfg *config.Config, telegrafDir string, dryRun bool) error {
 	logging.Info("Starting Telegraf drop-in generation...")
 
-	if err := os.MkdirAll(telegrafDir, 0755); err != nil {
+	if dryRun {
+		if _, err := os.Stat(telegrafDir); os.IsNotExist(err) {
+			logging.Infof("WOULD CREATE telegraf directory %s", telegrafDir)
+		}
+	} else if err := os.MkdirAll(telegrafDir, 0755); err != nil {
 		return fmt.Errorf("failed to create telegraf directory %s: %w", telegrafDir, err)
 	}
+	logTelegrafDir(telegrafDir)
 
-	// === Clean up old generated input files (05-inputs-*.conf) ===
+	// Fail before touching any file rather than halfway through, e.g. on a read-only mount
+	if !dryRun {
+		if err := checkWritable(telegrafDir); err != nil {
+			return err
+		}
+	}
+
+	// === Find old generated files (00-outputs-discard.conf, 01-outputs-*.conf, 05-inputs-*.conf, 90-uptime-kuma-push-*.conf), removed unless regenerated ===
 	entries, err := os.ReadDir(telegrafDir)
-	if err != nil {
+	if err != nil && !(dryRun && os.IsNotExist(err)) {
 		return fmt.Errorf("failed to read telegraf dir: %w", err)
 	}
+	staleFiles := make(map[string]bool)
 	for _, entry := range entries {
 		name := entry.Name()
-		if strings.HasPrefix(name, "05-inputs-") && strings.HasSuffix(name, ".conf") {
-			if err := os.Remove(filepath.Join(telegrafDir, name)); err != nil {
-				logging.Warnf("Warning: failed to remove old input file %s: %v", name, err)
-			} else {
-				logging.Infof("Removed old input config: %s", name)
-			}
+		if strings.Contains(name, ".conf.tmp-") && !dryRun {
+			os.Remove(filepath.Join(telegrafDir, name)) // left behind by an interrupted write
+			continue
+		}
+		if (strings.HasPrefix(name, "01-outputs-") || strings.HasPrefix(name, "05-inputs-") || strings.HasPrefix(name, "90-uptime-kuma-push-") || name == "00-outputs-discard.conf") && strings.HasSuffix(name, ".conf") {
+			staleFiles[name] = true
 		}
 	}
 
 	// === Determine needed metric types and collect disk mount points ===
 	type metricInfo struct {
 		Field         string
+		Fields        []string // field plus the extra and ping fields, for fieldinclude
+		TagFilters    []string // key=value tags push-metric filters series by
 		Threshold     float64
+		Operator      string
+		Unit          string
 		Token         string
 		Name          string
 		Group         string
 		Filesystem    string // only for disk
+		Interface     string // only for net
+		PerCPU        bool   // only for cpu
+		Sensor        string // only for temp
+		CustomInput   string // raw Telegraf input TOML
 		ContainerName string // only for docker
+		Spec          config.PushSpec
 	}
 
 	neededMetrics := make(map[string]bool)           // "cpu", "mem", "disk"
+	intervals := make(inputIntervals)                // input -> requested collection interval
 	monitorByMetric := make(map[string][]metricInfo) // for validation and outputs
 
 	var diskMountPoints []string
 	diskSeen := make(map[string]bool)
 
+	var netInterfaces []string
+	netSeen := make(map[string]bool)
+	netAllInterfaces := false // a net monitor without an interface needs every interface
+
+	// push-metric's log directory: the one mounted into the exec container, or the host's
+	pushLogDirectory := logging.GetInternalLogDirectory(&cfg.Agent.Logging)
+	if cfg.ExecMode() == config.ExecModeBinary {
+		pushLogDirectory = logging.GetHostLogDirectory(&cfg.Agent.Logging)
+	}
+
 	allMonitors := cfg.GetAllMonitors()
 	for i := range allMonitors {
 		m := &allMonitors[i]
@@ -67,16 +191,34 @@ func GenerateTelegrafConfigs(cfg *config.Config, telegrafDir string) error {
 
 		m.ResolveMetrics(cfg) // use global thresholds for defaults
 
+		if m.Metric == "disk" && strings.TrimSpace(m.Filesystem) == "" {
+			// Without a mount point the input would report every filesystem
+			logging.Warnf("Skipping disk monitor %q: no filesystem set (e.g. filesystem: \"/\")", m.Name)
+			continue
+		}
+
 		neededMetrics[m.Metric] = true
+		if m.InputInterval != "" && m.CustomInput == "" {
+			intervals.request(inputName(m.Metric), m.InputInterval, m.Name)
+		}
 
 		info := metricInfo{
 			Field:         m.Field,
-			Threshold:     m.Threshold,
+			Fields:        m.PushFields(),
+			TagFilters:    m.TagFilters(),
+			Threshold:     *m.Threshold, // set by ResolveMetrics
+			Operator:      m.Operator,
+			Unit:          m.ValueUnit(),
 			Token:         m.PushToken,
 			Name:          m.Name,
 			Group:         m.Group,
 			Filesystem:    m.Filesystem,
+			Interface:     m.Interface,
+			PerCPU:        m.PerCPU,
+			Sensor:        m.Sensor,
+			CustomInput:   m.CustomInput,
 			ContainerName: m.ContainerName,
+			Spec:          config.NewPushSpec(cfg, m, pushLogDirectory),
 		}
 		monitorByMetric[m.Metric] = append(monitorByMetric[m.Metric], info)
 
@@ -87,6 +229,43 @@ func GenerateTelegrafConfigs(cfg *config.Config, telegrafDir string) error {
 				diskMountPoints = append(diskMountPoints, fs)
 			}
 		}
+
+		if m.Metric == "net" {
+			iface := strings.TrimSpace(m.Interface)
+			if iface == "" {
+				netAllInterfaces = true
+			} else if !netSeen[iface] {
+				netSeen[iface] = true
+				netInterfaces = append(netInterfaces, iface)
+			}
+		}
+	}
+
+	// === Helper: write generated content (or only log it in dry-run) ===
+	writeOutput := func(outputPath, output string) error {
+		if !strings.HasSuffix(output, "\n") {
+			output += "\n"
+		}
+		delete(staleFiles, filepath.Base(outputPath))
+
+		// Leave identical files alone so Telegraf doesn't reload for nothing
+		if existing, err := os.ReadFile(outputPath); err == nil && string(existing) == output {
+			logging.Debugf("Unchanged: %s", outputPath)
+			return nil
+		}
+
+		if dryRun {
+			logging.Infof("WOULD WRITE: %s", outputPath)
+			return nil
+		}
+
+		// The temp file doesn't end in .conf, so Telegraf's config reload ignores it
+		if err := util.WriteFileAtomic(outputPath, []byte(output), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", outputPath, err)
+		}
+
+		logging.Infof("Generated: %s", outputPath)
+		return nil
 	}
 
 	// === Helper: render embedded template to file ===
@@ -99,14 +278,25 @@ func GenerateTelegrafConfigs(cfg *config.Config, telegrafDir string) error {
 		// Create template with functions BEFORE parsing
 		tmpl := template.New(filepath.Base(templatePath)).Funcs(template.FuncMap{
 			"sanitize": func(s string) string {
-				return provision.SanitizeFilename(s, "-") // hyphens for filenames
+				return util.SanitizeFilename(s, "-") // hyphens for filenames
 			},
 			"sanitizeMetric": func(s string) string {
-				return provision.SanitizeFilename(s, "_") // underscores for metric names
+				return util.SanitizeFilename(s, "_") // underscores for metric names
 			},
 			"hasPrefix": func(s, prefix string) bool {
 				return strings.HasPrefix(s, prefix)
 			},
+			"formatFloat": func(f float64) string {
+				return strconv.FormatFloat(f, 'f', -1, 64) // no exponent, unlike %v
+			},
+			"tomlString": func(s string) (string, error) {
+				// Quotes and backslashes read better in a literal string, which can't hold ' or control characters
+				if strings.ContainsAny(s, `"\`) && !strings.ContainsFunc(s, func(r rune) bool { return r == '\'' || unicode.IsControl(r) }) {
+					return "'" + s + "'", nil
+				}
+				b, err := json.Marshal(s) // JSON string escapes are valid in TOML basic strings
+				return string(b), err
+			},
 		})
 
 		// Now parse the template
@@ -120,31 +310,60 @@ func GenerateTelegrafConfigs(cfg *config.Config, telegrafDir string) error {
 			return fmt.Errorf("failed to execute template %s: %w", templatePath, err)
 		}
 
-		output := buf.String()
-		if !strings.HasSuffix(output, "\n") {
-			output += "\n"
-		}
-
-		if err := os.WriteFile(outputPath, []byte(output), 0644); err != nil {
-			return fmt.Errorf("failed to write %s: %w", outputPath, err)
-		}
-
-		logging.Infof("Generated: %s", outputPath)
-		return nil
+		return writeOutput(outputPath, buf.String())
 	}
 
 	// === 1. Generate input configs only if needed ===
 
 	if neededMetrics["cpu"] {
+		// cpu-total is always collected; per-core series only when a monitor asks for them
+		perCPU := false
+		for _, m := range monitorByMetric["cpu"] {
+			perCPU = perCPU || m.PerCPU
+		}
 		if err := renderTemplate("templates/inputs_cpu.tmpl",
-			filepath.Join(telegrafDir, "05-inputs-cpu.conf"), nil); err != nil {
+			filepath.Join(telegrafDir, "05-inputs-cpu.conf"),
+			struct {
+				PerCPU   bool
+				Interval string
+			}{PerCPU: perCPU, Interval: intervals.interval("cpu")},
+		); err != nil {
 			return err
 		}
 	}
 
 	if neededMetrics["mem"] {
 		if err := renderTemplate("templates/inputs_mem.tmpl",
-			filepath.Join(telegrafDir, "05-inputs-mem.conf"), nil); err != nil {
+			filepath.Join(telegrafDir, "05-inputs-mem.conf"),
+			struct{ Interval string }{Interval: intervals.interval("mem")},
+		); err != nil {
+			return err
+		}
+	}
+
+	if neededMetrics["system"] {
+		if err := renderTemplate("templates/inputs_system.tmpl",
+			filepath.Join(telegrafDir, "05-inputs-system.conf"),
+			struct{ Interval string }{Interval: intervals.interval("system")},
+		); err != nil {
+			return err
+		}
+	}
+
+	if neededMetrics["temp"] {
+		if err := renderTemplate("templates/inputs_temp.tmpl",
+			filepath.Join(telegrafDir, "05-inputs-temp.conf"),
+			struct{ Interval string }{Interval: intervals.interval("temp")},
+		); err != nil {
+			return err
+		}
+	}
+
+	if neededMetrics["swap"] {
+		if err := renderTemplate("templates/inputs_swap.tmpl",
+			filepath.Join(telegrafDir, "05-inputs-swap.conf"),
+			struct{ Interval string }{Interval: intervals.interval("swap")},
+		); err != nil {
 			return err
 		}
 	}
@@ -160,7 +379,9 @@ func GenerateTelegrafConfigs(cfg *config.Config, telegrafDir string) error {
 
 	if hasDockerMetric {
 		if err := renderTemplate("templates/inputs_docker.tmpl",
-			filepath.Join(telegrafDir, "05-inputs-docker.conf"), nil); err != nil {
+			filepath.Join(telegrafDir, "05-inputs-docker.conf"),
+			struct{ Interval string }{Interval: intervals.interval("docker")},
+		); err != nil {
 			return err
 		}
 	}
@@ -169,20 +390,58 @@ func GenerateTelegrafConfigs(cfg *config.Config, telegrafDir string) error {
 		sort.Strings(diskMountPoints) // deterministic
 		if err := renderTemplate("templates/inputs_disk.tmpl",
 			filepath.Join(telegrafDir, "05-inputs-disk.conf"),
-			struct{ MountPoints []string }{MountPoints: diskMountPoints},
+			struct {
+				MountPoints []string
+				Interval    string
+			}{MountPoints: diskMountPoints, Interval: intervals.interval("disk")},
+		); err != nil {
+			return err
+		}
+	}
+
+	if neededMetrics["net"] {
+		if netAllInterfaces {
+			netInterfaces = nil // unset interfaces = all interfaces
+		}
+		sort.Strings(netInterfaces) // deterministic
+		if err := renderTemplate("templates/inputs_net.tmpl",
+			filepath.Join(telegrafDir, "05-inputs-net.conf"),
+			struct {
+				Interfaces []string
+				Interval   string
+			}{Interfaces: netInterfaces, Interval: intervals.interval("net")},
+		); err != nil {
+			return err
+		}
+	}
+
+	// === 2. Generate the configured outputs, and outputs.discard unless there is a real one ===
+	usedOutputNames := make(map[string]bool)
+	for _, o := range cfg.Agent.Outputs {
+		// Like a broken custom_input, a broken output would stop Telegraf from loading
+		body, err := o.TOML()
+		if err != nil {
+			logging.Errorf("Skipping Telegraf output: %v", err)
+			continue
+		}
+		name := uniqueFileName(util.SanitizeFilename(o.FileName(), "-"), o.Plugin, usedOutputNames)
+		if err := renderTemplate("templates/outputs_plugin.tmpl",
+			filepath.Join(telegrafDir, fmt.Sprintf("01-outputs-%s.conf", name)),
+			struct {
+				Name string
+				Body string
+			}{Name: o.FileName(), Body: body},
 		); err != nil {
 			return err
 		}
 	}
 
-	// === 2. Generate global outputs.discard if configured ===
-	useOutputsDiscard := true
+	useOutputsDiscard := len(cfg.Agent.Outputs) == 0
 	if cfg.Agent.UseOutputsDiscard != nil {
 		useOutputsDiscard = *cfg.Agent.UseOutputsDiscard
 	}
 
 	if useOutputsDiscard {
-		// Assuming you have this template embedded
 		if err := renderTemplate("templates/outputs_discard.tmpl",
 			filepath.Join(telegrafDir, "00-outputs-discard.conf"), nil); err != nil {
 			return err
@@ -190,10 +449,39 @@ func GenerateTelegrafConfigs(cfg *config.Config, telegrafDir string) error {
 	}
 
 	// === 3. Generate one outputs.exec per push monitor ===
+	binaryPath := ""
+	switch cfg.ExecMode() {
+	case config.ExecModeDocker: // rendered from docker_image
+	case config.ExecModeBinary:
+		binaryPath = cfg.BinaryPath()
+		if err := config.CheckExecBinary(binaryPath); err != nil && len(monitorByMetric) > 0 {
+			return fmt.Errorf("agent.exec_mode is binary, but the agent binary can't be run: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown agent.exec_mode %q (expected %s)", cfg.Agent.ExecMode, strings.Join(config.ExecModes, " or "))
+	}
+
+	dockerImage := cfg.Agent.DockerImage
+	if binaryPath == "" && cfg.Agent.PinImageDigest != nil && *cfg.Agent.PinImageDigest && len(monitorByMetric) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), digestTimeout)
+		pinned, err := pinImage(ctx, &http.Client{Timeout: digestTimeout}, dockerImage)
+		cancel()
+		if err != nil {
+			logging.Warnf("Not pinning %s to a digest: %v", dockerImage, err)
+		} else {
+			logging.Infof("Pinned exec image: %s", pinned)
+			dockerImage = pinned
+		}
+	}
 	pushCount := 0
-	for metric, monitors := range monitorByMetric {
-		for _, m := range monitors {
-			pushCount++
+	metrics := make([]string, 0, len(monitorByMetric))
+	for metric := range monitorByMetric {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics) // stable order, so disambiguated file names don't move between runs
+	usedNames := make(map[string]bool)
+	for _, metric := range metrics {
+		for _, m := range monitorByMetric[metric] {
 
 			// Create unique filename using monitor name and group (if present)
 			// Use proper sanitization to handle special characters
@@ -204,50 +492,99 @@ func GenerateTelegrafConfigs(cfg *config.Config, telegrafDir string) error {
 				uniqueName = m.Name
 			}
 
-			safeName := provision.SanitizeFilename(uniqueName, "-")
+			safeName := uniqueFileName(util.SanitizeFilename(uniqueName, "-"), metric, usedNames)
 			filename := fmt.Sprintf("90-uptime-kuma-push-%s.conf", safeName)
 			path := filepath.Join(telegrafDir, filename)
 
+			// A broken snippet would stop Telegraf from loading any config, so skip the monitor
+			if m.CustomInput != "" {
+				if err := config.CheckTOML(m.CustomInput); err != nil {
+					logging.Errorf("Skipping push monitor %s: invalid custom_input: %v", m.Name, err)
+					continue
+				}
+				customPath := filepath.Join(telegrafDir, fmt.Sprintf("05-inputs-custom-%s.conf", safeName))
+				if err := writeOutput(customPath, m.CustomInput); err != nil {
+					return err
+				}
+			}
+			pushCount++
+
 			// Determine log directories from logging config
 			hostLogDirectory := logging.GetHostLogDirectory(&cfg.Agent.Logging)
 			internalLogDirectory := logging.GetInternalLogDirectory(&cfg.Agent.Logging)
 
+			// Everything push-metric needs, so it doesn't load the config on every flush
+			spec, err := json.Marshal(m.Spec)
+			if err != nil {
+				return fmt.Errorf("failed to encode push spec of %s: %w", m.Name, err)
+			}
+
 			data := struct {
 				DockerImage          string
+				BinaryPath           string // set for exec_mode binary
 				MonitorName          string
 				Group                string
 				Token                string
 				Metric               string
 				Field                string
+				Fields               []string
+				TagFilters           []string
 				Threshold            float64
+				Operator             string
+				Unit                 string
 				ContainerName        string
 				Filesystem           string
+				Interface            string
+				PerCPU               bool
+				Sensor               string
 				HostLogDirectory     string
 				InternalLogDirectory string
+				Spec                 string
 			}{
-				DockerImage:          cfg.Agent.DockerImage,
+				DockerImage:          dockerImage,
+				BinaryPath:           binaryPath,
 				MonitorName:          m.Name,
 				Group:                m.Group,
 				Token:                m.Token,
 				Metric:               metric,
 				Field:                m.Field,
+				Fields:               m.Fields,
+				TagFilters:           m.TagFilters,
 				Threshold:            m.Threshold,
+				Operator:             m.Operator,
+				Unit:                 m.Unit,
 				ContainerName:        m.ContainerName,
 				Filesystem:           m.Filesystem,
+				Interface:            m.Interface,
+				PerCPU:               m.PerCPU,
+				Sensor:               m.Sensor,
 				HostLogDirectory:     hostLogDirectory,
 				InternalLogDirectory: internalLogDirectory,
+				Spec:                 string(spec),
 			}
 
-			// You'll need this template too: templates/outputs_exec_push.tmpl
 			if err := renderTemplate("templates/outputs_exec_push.tmpl", path, data); err != nil {
 				return err
 			}
 		}
 	}
 
-	logging.Infof("Telegraf generation complete: %d push monitor(s), inputs: cpu=%v mem=%v disk=%v, discard=%v",
+	// === 4. Remove generated configs no monitor needs anymore (only the agent's own names) ===
+	for name := range staleFiles {
+		if dryRun {
+			logging.Infof("WOULD REMOVE old config: %s", name)
+			continue
+		}
+		if err := os.Remove(filepath.Join(telegrafDir, name)); err != nil {
+			logging.Warnf("Warning: failed to remove old config %s: %v", name, err)
+		} else {
+			logging.Infof("Removed old config: %s", name)
+		}
+	}
+
+	logging.Infof("Telegraf generation complete: %d push monitor(s), inputs: cpu=%v mem=%v swap=%v system=%v temp=%v disk=%v net=%v, discard=%v",
 		pushCount,
-		neededMetrics["cpu"], neededMetrics["mem"], len(diskMountPoints) > 0,
+		neededMetrics["cpu"], neededMetrics["mem"], neededMetrics["swap"], neededMetrics["system"], neededMetrics["temp"], len(diskMountPoints) > 0, neededMetrics["net"],
 		useOutputsDiscard)
 
 	return nil
@@ -0,0 +1,203 @@
+package telegraf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/gitisz/uptime-kuma-agent/internal/config"
+	"github.com/gitisz/uptime-kuma-agent/internal/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// pushConfig returns a config with the given push monitors and a fixed exec image.
+func pushConfig(monitors ...config.MonitorConfig) *config.Config {
+	cfg := &config.Config{UptimeKumaURL: "http://kuma:3001", PushMonitors: monitors}
+	cfg.Agent.DockerImage = "ghcr.io/example/agent:1"
+	return cfg
+}
+
+// execOutput decodes the outputs.exec section of the generated push config file.
+func execOutput(t *testing.T, path string) map[string]any {
+	t.Helper()
+	var doc struct {
+		Outputs struct {
+			Exec []map[string]any `toml:"exec"`
+		} `toml:"outputs"`
+	}
+	if _, err := toml.DecodeFile(path, &doc); err != nil {
+		t.Fatalf("generated %s is not valid TOML: %v", filepath.Base(path), err)
+	}
+	if len(doc.Outputs.Exec) != 1 {
+		t.Fatalf("%s has %d outputs.exec sections, want 1", filepath.Base(path), len(doc.Outputs.Exec))
+	}
+	return doc.Outputs.Exec[0]
+}
+
+// pushFiles returns the generated push config files in dir.
+func pushFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "90-uptime-kuma-push-*.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// captureLogs sends the agent's log output to the returned buffer for the rest of the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	saved := logging.Logger
+	logging.Logger = logrus.New()
+	logging.Logger.SetOutput(&buf)
+	logging.Logger.SetFormatter(&logging.CustomFormatter{})
+	t.Cleanup(func() { logging.Logger = saved })
+	return &buf
+}
+
+// argAfter returns the command argument following flag, or "".
+func argAfter(command []any, flag string) string {
+	for i := 0; i+1 < len(command); i++ {
+		if command[i] == flag {
+			s, _ := command[i+1].(string)
+			return s
+		}
+	}
+	return ""
+}
+
+func TestExecCommandQuotesConfigValues(t *testing.T) {
+	dir := t.TempDir()
+	cfg := pushConfig(config.MonitorConfig{
+		Name:       `Disk "data" \ backup`,
+		Group:      `Ops's team`,
+		Type:       "push",
+		Metric:     "disk",
+		Filesystem: `/mnt/"data"`,
+		PushToken:  `tok"en`,
+		Tags:       []config.TagConfig{{Name: "env", Value: `a"b`}},
+	})
+	cfg.Agent.DockerImage = `registry.example.com/agent:"1"`
+
+	if err := GenerateTelegrafConfigs(cfg, dir, false); err != nil {
+		t.Fatal(err)
+	}
+	files := pushFiles(t, dir)
+	if len(files) != 1 {
+		t.Fatalf("generated %d push configs, want 1", len(files))
+	}
+
+	exec := execOutput(t, files[0])
+	command, _ := exec["command"].([]any)
+	for flag, want := range map[string]string{
+		"--monitor": `Disk "data" \ backup`,
+		"--group":   `Ops's team`,
+		"--token":   `tok"en`,
+	} {
+		if got := argAfter(command, flag); got != want {
+			t.Errorf("%s = %q, want %q", flag, got, want)
+		}
+	}
+	if !reflect.DeepEqual(command[:1], []any{"docker"}) || !containsArg(command, `registry.example.com/agent:"1"`) {
+		t.Errorf("command doesn't run the configured image: %v", command)
+	}
+
+	tagpass, _ := exec["tagpass"].(map[string]any)
+	if got := tagpass["path"]; !reflect.DeepEqual(got, []any{`/mnt/"data"`}) {
+		t.Errorf("tagpass path = %v, want the configured filesystem", got)
+	}
+}
+
+// containsArg reports whether command has the argument arg.
+func containsArg(command []any, arg string) bool {
+	for _, a := range command {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDiskMonitorWithoutFilesystemIsSkipped(t *testing.T) {
+	logs := captureLogs(t)
+	dir := t.TempDir()
+	cfg := pushConfig(
+		config.MonitorConfig{Name: "Data Disk", Type: "push", Metric: "disk", PushToken: "t1"},
+		config.MonitorConfig{Name: "Root Disk", Type: "push", Metric: "disk", Filesystem: "/", PushToken: "t2"},
+	)
+
+	if err := GenerateTelegrafConfigs(cfg, dir, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(logs.String(), `Skipping disk monitor "Data Disk": no filesystem set`) {
+		t.Errorf("no warning about the disk monitor without a filesystem; logs:\n%s", logs)
+	}
+	files := pushFiles(t, dir)
+	if len(files) != 1 || !strings.Contains(files[0], "root-disk") {
+		t.Fatalf("push configs = %v, want only the one for Root Disk", files)
+	}
+	if got := execOutput(t, files[0])["tagpass"]; !reflect.DeepEqual(got, map[string]any{"path": []any{"/"}}) {
+		t.Errorf("Root Disk tagpass = %v, want path /", got)
+	}
+
+	inputs, err := os.ReadFile(filepath.Join(dir, "05-inputs-disk.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(inputs), `mount_points = ["/"]`) || strings.Contains(string(inputs), "/mnt/data") {
+		t.Errorf("disk input should collect only /:\n%s", inputs)
+	}
+}
+
+func TestUniqueFileName(t *testing.T) {
+	used := make(map[string]bool)
+	steps := []struct {
+		name, metric, want string
+	}{
+		{"disk-servers", "disk", "disk-servers"},
+		{"disk-servers", "disk", "disk-servers-disk"},
+		{"disk-servers", "disk", "disk-servers-disk-2"},
+		{"disk-servers", "disk", "disk-servers-disk-3"},
+		{"web", "docker_container_cpu", "web"},
+		{"web", "docker_container_cpu", "web-docker_container_cpu"},
+		{"web", "docker_container_mem", "web-docker_container_mem"},
+	}
+	for _, s := range steps {
+		if got := uniqueFileName(s.name, s.metric, used); got != s.want {
+			t.Errorf("uniqueFileName(%q, %q) = %q, want %q", s.name, s.metric, got, s.want)
+		}
+	}
+}
+
+func TestPushFileNamesStayDistinct(t *testing.T) {
+	dir := t.TempDir()
+	cfg := pushConfig(
+		// All three sanitize to "cpu-usage"
+		config.MonitorConfig{Name: "CPU Usage", Type: "push", Metric: "cpu", PushToken: "t1"},
+		config.MonitorConfig{Name: "cpu usage", Type: "push", Metric: "mem", PushToken: "t2"},
+		config.MonitorConfig{Name: "CPU/Usage", Type: "push", Metric: "cpu", PushToken: "t3"},
+	)
+
+	if err := GenerateTelegrafConfigs(cfg, dir, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range pushFiles(t, dir) {
+		got = append(got, filepath.Base(f))
+	}
+	want := []string{
+		"90-uptime-kuma-push-cpu-usage-cpu.conf",
+		"90-uptime-kuma-push-cpu-usage-mem.conf",
+		"90-uptime-kuma-push-cpu-usage.conf",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("push configs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tempFiles returns the leftover WriteFileAtomic temp files in dir.
+func tempFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmp-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "05-inputs-cpu.conf")
+
+	for _, content := range []string{"first\n", "second\n"} {
+		if err := WriteFileAtomic(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("file holds %q, want %q", data, content)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("file mode = %v (%v), want 0644", info.Mode().Perm(), err)
+	}
+	if left := tempFiles(t, dir); len(left) != 0 {
+		t.Errorf("temp files left behind: %v", left)
+	}
+
+	// Replacing a file takes the new mode, not the old file's
+	if err := WriteFileAtomic(path, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func TestWriteFileAtomicFailedRename(t *testing.T) {
+	dir := t.TempDir()
+
+	// A non-empty directory in the way makes the final rename fail, even for root
+	path := filepath.Join(dir, "90-uptime-kuma-push-disk.conf")
+	if err := os.MkdirAll(filepath.Join(path, "keep"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFileAtomic(path, []byte("[[outputs.exec]]\n"), 0o644); err == nil {
+		t.Fatal("WriteFileAtomic succeeded, want the rename error")
+	}
+	if left := tempFiles(t, dir); len(left) != 0 {
+		t.Errorf("temp files left behind: %v", left)
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		t.Errorf("target replaced by a partial write: %v, %v", info, err)
+	}
+}
+
+func TestWriteFileAtomicMissingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "missing", "05-inputs-cpu.conf")
+
+	if err := WriteFileAtomic(path, []byte("data"), 0o644); err == nil {
+		t.Fatal("WriteFileAtomic succeeded without its directory")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("files created in %s: %v", dir, entries)
+	}
+}
@@ -0,0 +1,71 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var invalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// SanitizeFilename turns a monitor or metric name into a lowercase identifier of at
+// most 50 characters using only letters, digits and delimiter. All file and metric
+// names derived from config names go through it, so they always agree.
+func SanitizeFilename(name string, delimiter string) string {
+	original := name
+
+	// 1. To lowercase
+	name = strings.ToLower(name)
+
+	// 2. Replace hyphens with delimiter (for metric names, we want underscores everywhere)
+	if delimiter == "_" {
+		name = strings.ReplaceAll(name, "-", delimiter)
+	}
+
+	// 3. Replace any remaining invalid chars with delimiter
+	name = invalidChars.ReplaceAllString(name, delimiter)
+
+	// 4. Collapse multiple delimiters into one
+	var multipleDelimiters = regexp.MustCompile(delimiter + "+")
+	name = multipleDelimiters.ReplaceAllString(name, delimiter)
+
+	// 5. Non-ASCII characters were dropped above, so names differing only in them (e.g.
+	// Japanese names) get a short hash of the original name to stay distinct
+	suffix := ""
+	if !isASCII(original) {
+		sum := sha256.Sum256([]byte(original))
+		suffix = delimiter + hex.EncodeToString(sum[:4])
+	}
+
+	// 6. Truncate to max length (before final trim)
+	if maxLen := 50 - len(suffix); len(name) > maxLen {
+		name = name[:maxLen]
+	}
+
+	// 7. Trim leading/trailing delimiters
+	name = strings.Trim(name, delimiter)
+
+	// 8. Fallback if name became empty
+	if name == "" {
+		name = "monitor"
+	}
+
+	return name + suffix
+}
+
+// isASCII reports whether s consists of ASCII characters only.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+// Backward compatibility wrapper.
+func SanitizeFilenameHyphen(name string) string {
+	return SanitizeFilename(name, "-")
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeFilenameNonASCII(t *testing.T) {
+	names := []string{
+		"ディスク使用量",
+		"メモリ使用量",
+		"CPU 使用率",
+		"CPU 温度",
+		"🔥",
+		"🚀🚀",
+		"Disk 💾",
+	}
+
+	for _, delimiter := range []string{"-", "_"} {
+		seen := make(map[string]string)
+		for _, name := range names {
+			got := SanitizeFilename(name, delimiter)
+			if got == "" {
+				t.Errorf("SanitizeFilename(%q, %q) is empty", name, delimiter)
+			}
+			if len(got) > 50 {
+				t.Errorf("SanitizeFilename(%q, %q) = %q, longer than 50 bytes", name, delimiter, got)
+			}
+			if strings.Trim(got, "abcdefghijklmnopqrstuvwxyz0123456789"+delimiter) != "" {
+				t.Errorf("SanitizeFilename(%q, %q) = %q, has characters besides a-z, 0-9 and %q", name, delimiter, got, delimiter)
+			}
+			if other, ok := seen[got]; ok {
+				t.Errorf("SanitizeFilename(%q, %q) = %q, same as for %q", name, delimiter, got, other)
+			}
+			seen[got] = name
+		}
+	}
+
+	long := strings.Repeat("ディスク", 30) + " usage"
+	if got := SanitizeFilename(long, "-"); len(got) > 50 {
+		t.Errorf("SanitizeFilename of a long name = %q, longer than 50 bytes", got)
+	}
+}
+
+func TestSanitizeFilenameASCII(t *testing.T) {
+	tests := []struct {
+		name, delimiter, want string
+	}{
+		{"Disk Usage", "-", "disk-usage"},
+		{"cpu-total", "_", "cpu_total"},
+		{"  web / api (prod)  ", "-", "web-api-prod"},
+		{"mem_used", "-", "mem_used"},
+		{"!!!", "-", "monitor"},
+		{strings.Repeat("a", 60), "-", strings.Repeat("a", 50)},
+	}
+	for _, tt := range tests {
+		if got := SanitizeFilename(tt.name, tt.delimiter); got != tt.want {
+			t.Errorf("SanitizeFilename(%q, %q) = %q, want %q", tt.name, tt.delimiter, got, tt.want)
+		}
+	}
+}